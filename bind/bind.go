@@ -1,57 +1,86 @@
 package bind
 
 import (
-	"github.com/gookit/validate"
-	"github.com/gorilla/schema"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
 	"github.com/ninthsoft/seed"
-	"github.com/ninthsoft/seed/render"
 )
 
 type BindType int
 
 const (
+	// JSON 请求体按 json 解析，使用 json tag
 	JSON BindType = iota
+	// Query url 中的参数，使用 query tag，没有时按 schema tag 或字段名匹配
 	Query
+	// Form url 与请求体中的表单参数，使用 form tag，没有时按 schema tag 或字段名匹配
 	Form
+	// PostForm 请求体中的表单参数，使用 form tag，没有时按 schema tag 或字段名匹配
 	PostForm
+	// MultipartForm multipart 请求体中的表单参数，使用 form tag，没有时按 schema tag 或字段名匹配
 	MultipartForm
+	// Path 路由中的路径参数，使用 path tag
+	Path
+	// Header 请求头，使用 header tag
+	Header
+	// Cookie 请求 Cookie，使用 cookie tag
+	Cookie
 )
 
-var decoder = schema.NewDecoder()
+// DefaultBinder Should 使用的 Binder
+var DefaultBinder = NewBinder()
 
+// Should 使用 DefaultBinder 把请求参数绑定到 dst 并校验
+//
+// 	不指定 bts 时一次性绑定所有来源，后者覆盖前者:
+// 	请求体(根据 Content-Type 选择 json/form/multipart) -> query -> header -> cookie -> path
+// 	如:
+// 	type UpdateUser struct {
+// 		ID     int    `path:"id"`
+// 		Tenant string `header:"X-Tenant"`
+// 		SID    string `cookie:"sid"`
+// 		Page   int    `query:"page"`
+// 		Name   string `json:"name" form:"name" validate:"required"`
+// 	}
+// 	path/header/cookie 只绑定声明了对应 tag 的字段，只声明了其他来源 tag 的字段不会被 query/form 绑定，
+// 	json 请求体也不会覆盖只声明了 path/query/header/cookie tag 的字段
+// 	指定 bts 时只绑定指定的来源，按传入的顺序
+// 	校验失败时返回 *ValidationError
 func Should(r seed.Request, dst interface{}, bts ...BindType) (err error) {
-	if dst == nil {
-		return render.NewError("dst object cannot be nil", 4000)
+	return DefaultBinder.Should(r, dst, bts...)
+}
+
+// hasBody 请求是否可能带有请求体
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// bodyType 根据 Content-Type 选择请求体的绑定方式，未设置 Content-Type 时按 json 处理
+func bodyType(r *http.Request) (BindType, bool) {
+	var ct = r.Header.Get(seed.HeaderContentType)
+	if ct == "" {
+		return JSON, true
 	}
-	var bt = JSON
-	if len(bts) > 0 {
-		bt = bts[0]
+	var mediaType, _, err = mime.ParseMediaType(ct)
+	if err != nil {
+		return 0, false
 	}
-	var request = r.HTTPRequest()
-	_ = request.ParseForm()
-
-	var values = request.URL.Query()
-	switch bt {
-	case JSON:
-		if err = r.JsonUnmarshal(dst); err != nil {
-			return render.NewError(err.Error(), 4000)
-		}
-		if v := validate.Struct(dst); !v.Validate() {
-			return render.NewError(v.Errors.String(), 4000)
-		}
-	case Form:
-		values = request.Form
-	case PostForm:
-		values = request.PostForm
-	case MultipartForm:
-		values = request.MultipartForm.Value
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JSON, true
+	case mediaType == "application/x-www-form-urlencoded":
+		return PostForm, true
+	case mediaType == "multipart/form-data":
+		return MultipartForm, true
 	default:
+		return 0, false
 	}
-	if err = decoder.Decode(dst, values); err == nil {
-		if v := validate.Struct(dst); !v.Validate() {
-			return render.NewError(v.Errors.String(), 4000)
-		}
-		return
-	}
-	return render.NewError(err.Error(), 4000)
+}
+
+func isStructPtr(dst interface{}) bool {
+	var t = reflect.TypeOf(dst)
+	return t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
 }
@@ -0,0 +1,238 @@
+package bind
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+type updateUser struct {
+	ID     int    `path:"id"`
+	Tenant string `header:"X-Tenant"`
+	SID    string `cookie:"sid"`
+	Page   int    `query:"page"`
+	Name   string `json:"name" form:"name" validate:"required"`
+}
+
+// serveBind 通过路由执行绑定，以便带上路径参数
+func serveBind(t *testing.T, req *http.Request, dst interface{}, bts ...BindType) error {
+	t.Helper()
+	var err error
+	var r = seed.NewRouter()
+	r.HandleFunc("POST,PUT", "/user/:id", func(ctx context.Context, req seed.Request) seed.Response {
+		err = Should(req, dst, bts...)
+		return seed.NopResponse(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return err
+}
+
+func TestShouldBindsAllSources(t *testing.T) {
+	var cases = []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "json", contentType: "application/json", body: `{"name":"alice"}`},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "name=alice"},
+		{name: "multipart", contentType: "multipart/form-data; boundary=b", body: "--b\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nalice\r\n--b--\r\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var req = httptest.NewRequest(http.MethodPut, "/user/42?page=3", strings.NewReader(c.body))
+			req.Header.Set("Content-Type", c.contentType)
+			req.Header.Set("X-Tenant", "acme")
+			req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+
+			var dst updateUser
+			if err := serveBind(t, req, &dst); err != nil {
+				t.Fatal(err)
+			}
+			var want = updateUser{ID: 42, Tenant: "acme", SID: "s1", Page: 3, Name: "alice"}
+			if dst != want {
+				t.Fatalf("want %+v, got %+v", want, dst)
+			}
+		})
+	}
+}
+
+func TestShouldOnlyBindsTaggedHeaders(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodPost, "/user/1", strings.NewReader(`{"name":"bob"}`))
+	req.Header.Set("Name", "spoofed")
+
+	var dst updateUser
+	if err := serveBind(t, req, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "bob" {
+		t.Fatalf("want name %q, got %q", "bob", dst.Name)
+	}
+	if req.Header.Get("Name") != "spoofed" {
+		t.Fatal("binding must not modify the request headers")
+	}
+}
+
+func TestShouldDoesNotBindHeaderAndCookieFieldsFromOtherSources(t *testing.T) {
+	var cases = []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		bts         []BindType
+	}{
+		{name: "query", target: "/user/1?tenant=evil&sid=evil&Tenant=evil&SID=evil&X-Tenant=evil"},
+		{name: "query only", target: "/user/1?Tenant=evil&SID=evil", bts: []BindType{Query}},
+		{name: "json", target: "/user/1", contentType: "application/json", body: `{"Tenant":"evil","sid":"evil","ID":9,"Page":9}`},
+		{name: "form", target: "/user/1", contentType: "application/x-www-form-urlencoded", body: "Tenant=evil&SID=evil&ID=9"},
+		{name: "form only", target: "/user/1?Tenant=evil", contentType: "application/x-www-form-urlencoded", body: "SID=evil", bts: []BindType{Form}},
+		{name: "multipart", target: "/user/1", contentType: "multipart/form-data; boundary=b", body: "--b\r\nContent-Disposition: form-data; name=\"Tenant\"\r\n\r\nevil\r\n--b--\r\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var req = httptest.NewRequest(http.MethodPost, c.target, strings.NewReader(c.body))
+			if c.contentType != "" {
+				req.Header.Set("Content-Type", c.contentType)
+			}
+			var dst = updateUser{Name: "x"}
+			_ = serveBind(t, req, &dst, c.bts...)
+			if dst.Tenant != "" || dst.SID != "" || dst.Page != 0 || (len(c.bts) > 0 && dst.ID != 0) {
+				t.Fatalf("header/cookie/query fields bound from %s: %+v", c.name, dst)
+			}
+			if len(c.bts) == 0 && dst.ID != 1 {
+				t.Fatalf("want path id 1, got %d", dst.ID)
+			}
+		})
+	}
+}
+
+func TestShouldSelectedSources(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodPost, "/user/7", strings.NewReader("name=carol"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst updateUser
+	if err := serveBind(t, req, &dst, Path, PostForm); err != nil {
+		t.Fatal(err)
+	}
+	if dst.ID != 7 || dst.Name != "carol" {
+		t.Fatalf("unexpected result %+v", dst)
+	}
+}
+
+func TestShouldValidates(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodPost, "/user/1", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var dst updateUser
+	if err := serveBind(t, req, &dst); err == nil {
+		t.Fatal("want validation error for missing name")
+	}
+}
+
+type signUp struct {
+	Name    string `json:"name" validate:"required|min_len:3"`
+	Address struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+}
+
+func TestShouldReturnsFieldErrors(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodPost, "/user/1", strings.NewReader(`{"name":"ab"}`))
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+
+	DefaultTranslator = BuiltinTranslator
+	defer func() { DefaultTranslator = nil }()
+
+	var dst signUp
+	var err = serveBind(t, req, &dst)
+	var ve, ok = err.(*ValidationError)
+	if !ok {
+		t.Fatalf("want *ValidationError, got %T %v", err, err)
+	}
+	var want = []FieldError{
+		{Field: "address.city", Rule: "required", Message: "address.city不能为空", Value: ""},
+		{Field: "name", Rule: "min_len", Params: []string{"3"}, Message: "name长度不能小于3", Value: "ab"},
+	}
+	if !reflect.DeepEqual(ve.Fields, want) {
+		t.Fatalf("want %+v, got %+v", want, ve.Fields)
+	}
+}
+
+type searchQuery struct {
+	Since time.Time `query:"since"`
+	Limit int       `json:"limit"`
+}
+
+func TestBinderOptions(t *testing.T) {
+	var b = NewBinder(
+		WithConverter(time.Time{}, func(s string) reflect.Value {
+			if v, err := time.Parse(time.DateOnly, s); err == nil {
+				return reflect.ValueOf(v)
+			}
+			return reflect.Value{}
+		}),
+		WithDisallowUnknownFields(true),
+		WithValidator(ValidatorFunc(func(dst interface{}) error {
+			if dst.(*searchQuery).Limit > 100 {
+				return NewValidationError(FieldError{Field: "limit", Rule: "max", Params: []string{"100"}, Message: "too large"})
+			}
+			return nil
+		})),
+	)
+
+	var bindWith = func(body string) (searchQuery, error) {
+		var req = httptest.NewRequest(http.MethodPost, "/search?since=2024-05-01", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		var dst searchQuery
+		return dst, b.Should(seed.NewRequest(req), &dst)
+	}
+
+	var dst, err = bindWith(`{"limit":10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Since.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || dst.Limit != 10 {
+		t.Fatalf("unexpected result %+v", dst)
+	}
+
+	if _, err = bindWith(`{"limit":10,"offset":1}`); err == nil {
+		t.Fatal("want error for unknown json field")
+	}
+
+	_, err = bindWith(`{"limit":1000}`)
+	if ve, ok := err.(*ValidationError); !ok || ve.Fields[0].Rule != "max" {
+		t.Fatalf("want custom validator error, got %v", err)
+	}
+}
+
+type legacyQuery struct {
+	Keyword string `schema:"q"`
+	Limit   int
+	Tenant  string `header:"X-Tenant"`
+}
+
+func TestShouldQueryKeepsSchemaTagsAndFieldNames(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodGet, "/search?q=go&limit=5&tenant=evil", nil)
+	var dst legacyQuery
+	if err := Should(seed.NewRequest(req), &dst, Query); err != nil {
+		t.Fatal(err)
+	}
+	if want := (legacyQuery{Keyword: "go", Limit: 5}); dst != want {
+		t.Fatalf("want %+v, got %+v", want, dst)
+	}
+
+	var strict = NewBinder(WithIgnoreUnknownKeys(false))
+	req = httptest.NewRequest(http.MethodGet, "/search?q=go&limit=5", nil)
+	if err := strict.Should(seed.NewRequest(req), &legacyQuery{}, Query); err != nil {
+		t.Fatalf("want known keys accepted, got %v", err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/search?q=go&offset=1", nil)
+	if err := strict.Should(seed.NewRequest(req), &legacyQuery{}, Query); err == nil || !strings.Contains(err.Error(), "offset") {
+		t.Fatalf("want unknown key rejected, got %v", err)
+	}
+}
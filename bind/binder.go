@@ -0,0 +1,260 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/schema"
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+// defaultMaxMemory 解析 multipart 表单时内存中最多保留的字节数
+const defaultMaxMemory = 32 << 20
+
+// Option Binder 的配置项
+type Option func(b *Binder)
+
+// WithValidator 设置校验器，默认为 DefaultValidator
+func WithValidator(v Validator) Option {
+	return func(b *Binder) {
+		b.validator = v
+	}
+}
+
+// WithTranslator 设置校验错误信息的翻译器，默认使用 DefaultTranslator
+func WithTranslator(t Translator) Option {
+	return func(b *Binder) {
+		b.translator = t
+	}
+}
+
+// WithConverter 为 value 的类型注册 schema 转换器，如 time.Time、decimal、uuid 等
+//
+// 	对 query/form/path/header/cookie 来源都生效
+func WithConverter(value interface{}, converter schema.Converter) Option {
+	return func(b *Binder) {
+		b.converters = append(b.converters, converterEntry{value: value, converter: converter})
+	}
+}
+
+// WithIgnoreUnknownKeys 是否忽略 query/form 中结构体没有声明的参数，默认忽略
+func WithIgnoreUnknownKeys(ignore bool) Option {
+	return func(b *Binder) {
+		b.ignoreUnknownKeys = ignore
+	}
+}
+
+// WithZeroEmpty 参数值为空串时是否把字段置为零值，默认保留字段原值
+func WithZeroEmpty(zero bool) Option {
+	return func(b *Binder) {
+		b.zeroEmpty = zero
+	}
+}
+
+// WithDisallowUnknownFields json 请求体中出现结构体没有声明的字段时返回错误
+func WithDisallowUnknownFields(disallow bool) Option {
+	return func(b *Binder) {
+		b.disallowUnknownFields = disallow
+	}
+}
+
+// WithUseNumber json 请求体中的数字解析到 interface{} 时使用 json.Number 而不是 float64
+func WithUseNumber(useNumber bool) Option {
+	return func(b *Binder) {
+		b.useNumber = useNumber
+	}
+}
+
+// WithMaxMemory 解析 multipart 表单时内存中最多保留的字节数，默认 32MB
+func WithMaxMemory(n int64) Option {
+	return func(b *Binder) {
+		b.maxMemory = n
+	}
+}
+
+type converterEntry struct {
+	value     interface{}
+	converter schema.Converter
+}
+
+// Binder 请求参数绑定器，零值不可用，请使用 NewBinder 创建
+//
+// 	创建之后可以并发使用
+type Binder struct {
+	validator  Validator
+	translator Translator
+
+	converters        []converterEntry
+	ignoreUnknownKeys bool
+	zeroEmpty         bool
+
+	disallowUnknownFields bool
+	useNumber             bool
+	maxMemory             int64
+
+	decoders map[BindType]*schema.Decoder
+}
+
+// NewBinder 创建 Binder，opts 详见 Option
+func NewBinder(opts ...Option) *Binder {
+	var b = &Binder{
+		validator:         DefaultValidator,
+		ignoreUnknownKeys: true,
+		maxMemory:         defaultMaxMemory,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var form = b.newDecoder("form")
+	b.decoders = map[BindType]*schema.Decoder{
+		Query:         b.newDecoder("query"),
+		Form:          form,
+		PostForm:      form,
+		MultipartForm: form,
+		Path:          b.newDecoder("path"),
+		Header:        b.newDecoder("header"),
+		Cookie:        b.newDecoder("cookie"),
+	}
+	return b
+}
+
+func (b *Binder) newDecoder(tag string) *schema.Decoder {
+	var d = schema.NewDecoder()
+	d.SetAliasTag(tag)
+	d.IgnoreUnknownKeys(b.ignoreUnknownKeys)
+	d.ZeroEmpty(b.zeroEmpty)
+	for _, c := range b.converters {
+		d.RegisterConverter(c.value, c.converter)
+	}
+	return d
+}
+
+// Should 把请求参数绑定到 dst 并校验，规则同包级别的 Should
+func (b *Binder) Should(r seed.Request, dst interface{}, bts ...BindType) (err error) {
+	if dst == nil {
+		return render.NewError("dst object cannot be nil", 4000)
+	}
+	if len(bts) == 0 {
+		err = b.bindAll(r, dst)
+	} else {
+		for _, bt := range bts {
+			if err = b.bindOne(r, dst, bt); err != nil {
+				break
+			}
+		}
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return render.ErrBodyTooLarge
+	}
+	if err != nil {
+		return render.NewError(err.Error(), 4000)
+	}
+	return b.validate(r, dst)
+}
+
+func (b *Binder) validate(r seed.Request, dst interface{}) error {
+	if b.validator == nil {
+		return nil
+	}
+	var err = b.validator.Validate(dst)
+	if err == nil {
+		return nil
+	}
+	var ve, ok = err.(*ValidationError)
+	if !ok {
+		return render.NewError(err.Error(), 4000)
+	}
+
+	var tr = b.translator
+	if tr == nil {
+		tr = DefaultTranslator
+	}
+	if tr != nil {
+		var lang = acceptLanguage(r.HTTPRequest())
+		for i := range ve.Fields {
+			ve.Fields[i].Message = tr.Translate(lang, ve.Fields[i])
+		}
+	}
+	return ve
+}
+
+func (b *Binder) bindAll(r seed.Request, dst interface{}) error {
+	var request = r.HTTPRequest()
+	if hasBody(request) {
+		var bt, ok = bodyType(request)
+		if ok {
+			if err := b.bindOne(r, dst, bt); err != nil {
+				return err
+			}
+		}
+	}
+	if !isStructPtr(dst) {
+		return nil
+	}
+	for _, bt := range []BindType{Query, Header, Cookie, Path} {
+		if err := b.bindOne(r, dst, bt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Binder) bindOne(r seed.Request, dst interface{}, bt BindType) error {
+	var request = r.HTTPRequest()
+	var decoder = b.decoders[bt]
+	switch bt {
+	case JSON:
+		return b.bindJSON(r, dst)
+	case Query:
+		return decoder.Decode(dst, sourceValues(dst, "query", request.URL.Query()))
+	case Form:
+		if err := request.ParseForm(); err != nil {
+			return err
+		}
+		return decoder.Decode(dst, sourceValues(dst, "form", request.Form))
+	case PostForm:
+		if err := request.ParseForm(); err != nil {
+			return err
+		}
+		return decoder.Decode(dst, sourceValues(dst, "form", request.PostForm))
+	case MultipartForm:
+		if err := request.ParseMultipartForm(b.maxMemory); err != nil {
+			return err
+		}
+		return decoder.Decode(dst, sourceValues(dst, "form", request.MultipartForm.Value))
+	case Path:
+		return decoder.Decode(dst, onlyTagged(dst, "path", pathValues(request)))
+	case Header:
+		return decoder.Decode(dst, onlyTagged(dst, "header", headerValues(request)))
+	case Cookie:
+		return decoder.Decode(dst, onlyTagged(dst, "cookie", cookieValues(request)))
+	default:
+		return nil
+	}
+}
+
+func (b *Binder) bindJSON(r seed.Request, dst interface{}) error {
+	// json 按字段名不区分大小写匹配，只声明了其他来源的字段不能被请求体覆盖
+	defer keepSourceFields(dst)()
+	if !b.disallowUnknownFields && !b.useNumber {
+		return r.JsonUnmarshal(dst)
+	}
+
+	var bs, err = r.Body()
+	if err != nil {
+		return err
+	}
+	var dec = json.NewDecoder(bytes.NewReader(bs))
+	if b.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if b.useNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(dst)
+}
@@ -0,0 +1,122 @@
+package bind
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gookit/validate"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 字段路径，优先使用 json tag 中的名称，嵌套字段以 . 连接，如 address.city
+	Field string `json:"field"`
+	// Rule 未通过的校验规则，如 required、min_len
+	Rule string `json:"rule"`
+	// Params 校验规则的参数，如 min_len:7 中的 7
+	Params []string `json:"params,omitempty"`
+	// Message 提示信息，配置了 Translator 时为翻译后的信息
+	Message string `json:"message"`
+	// Value 被拒绝的值
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ValidationError 参数校验失败的错误，包含每个字段的错误信息
+//
+// 	render.DefaultJsonRender 会把 Fields 序列化到 details 字段
+type ValidationError struct {
+	code   int
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var msgs = make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Code 业务错误码
+func (e *ValidationError) Code() int {
+	return e.code
+}
+
+// Details 返回每个字段的错误信息
+func (e *ValidationError) Details() interface{} {
+	return e.Fields
+}
+
+// NewValidationError 创建 ValidationError，供自定义的 Validator 使用
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{code: 4000, Fields: fields}
+}
+
+// newValidationError 把 gookit/validate 的错误转换为 ValidationError，字段按名称排序
+func newValidationError(dst interface{}, errs validate.Errors) *ValidationError {
+	var e = NewValidationError()
+	for _, field := range slices.Sorted(maps.Keys(errs)) {
+		var value, tag = lookupField(reflect.ValueOf(dst), field)
+		for _, rule := range slices.Sorted(maps.Keys(errs[field])) {
+			e.Fields = append(e.Fields, FieldError{
+				Field:   field,
+				Rule:    rule,
+				Params:  ruleParams(tag, rule),
+				Message: errs[field][rule],
+				Value:   value,
+			})
+		}
+	}
+	return e
+}
+
+// lookupField 按字段路径查找字段的值与 validate tag，路径中的名称可以是 json 名称或字段名
+func lookupField(v reflect.Value, path string) (interface{}, string) {
+	var tag string
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, ""
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, ""
+		}
+		var sf, ok = findField(v.Type(), name)
+		if !ok {
+			return nil, ""
+		}
+		v = v.FieldByIndex(sf.Index)
+		tag = sf.Tag.Get("validate")
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, tag
+	}
+	return v.Interface(), tag
+}
+
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() {
+			continue
+		}
+		if jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ","); jsonName == name {
+			return sf, true
+		}
+	}
+	return t.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, name) })
+}
+
+// ruleParams 从 validate tag (如 "required|min_len:7") 中取出规则的参数
+func ruleParams(tag, rule string) []string {
+	for _, r := range strings.Split(tag, "|") {
+		var name, args, has = strings.Cut(strings.TrimSpace(r), ":")
+		if name == rule && has {
+			return strings.Split(args, ",")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package bind
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ninthsoft/seed"
+)
+
+func pathValues(r *http.Request) url.Values {
+	var ps = seed.ParamsFromContext(r.Context())
+	var values = make(url.Values, len(ps))
+	for _, p := range ps {
+		values.Add(p.Key, p.Value)
+	}
+	return values
+}
+
+func headerValues(r *http.Request) url.Values {
+	return url.Values(r.Header)
+}
+
+func cookieValues(r *http.Request) url.Values {
+	var values = url.Values{}
+	for _, c := range r.Cookies() {
+		values.Add(c.Name, c.Value)
+	}
+	return values
+}
+
+// onlyTagged 只保留 dst 中显式声明了 tag 的字段对应的值
+//
+// 	schema 在字段没有 tag 时会退化为使用字段名匹配，
+// 	客户端可以随意携带参数，需要避免只声明了 header/cookie 等来源的字段被其他来源误绑定
+// 	嵌套结构体的 key(如 addr.city)按第一段匹配
+func onlyTagged(dst interface{}, tag string, values url.Values) url.Values {
+	var tagged = url.Values{}
+	if !isStructPtr(dst) {
+		return tagged
+	}
+	var names = taggedNames(reflect.TypeOf(dst).Elem(), tag)
+	for k, vs := range values {
+		var head, _, _ = strings.Cut(k, ".")
+		if slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, k) || strings.EqualFold(n, head) }) {
+			tagged[k] = vs
+		}
+	}
+	return tagged
+}
+
+// taggedNames 返回结构体(含匿名嵌入的结构体)中 tag 声明的名称
+func taggedNames(t reflect.Type, tag string) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			names = append(names, name)
+			continue
+		}
+		var ft = f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			names = append(names, taggedNames(ft, tag)...)
+		}
+	}
+	return names
+}
+
+// sourceValues 整理 query/form 参数，tag 为 "query" 或 "form"
+//
+// 	没有 tag 的字段与之前一样按 schema tag 或字段名匹配，schema tag 的参数会改写为字段名交给 decoder
+// 	只声明了其他来源(path/header/cookie 等)的字段不能被 query/form 绑定，对应的参数会被丢弃
+// 	其余参数原样保留，是否忽略未知参数由 WithIgnoreUnknownKeys 决定
+func sourceValues(dst interface{}, tag string, values url.Values) url.Values {
+	if !isStructPtr(dst) {
+		return values
+	}
+	var fs = sourceFieldNames{}
+	fs.collect(reflect.TypeOf(dst).Elem(), tag)
+
+	var out = make(url.Values, len(values))
+	for k, vs := range values {
+		var head, rest, nested = strings.Cut(k, ".")
+		var match = func(n string) bool { return strings.EqualFold(n, head) }
+		switch {
+		case slices.ContainsFunc(fs.tagged, match):
+		case fs.schema[strings.ToLower(head)] != "":
+			k = fs.schema[strings.ToLower(head)]
+			if nested {
+				k += "." + rest
+			}
+		case slices.ContainsFunc(fs.others, match):
+			continue
+		}
+		out[k] = append(out[k], vs...)
+	}
+	return out
+}
+
+// sourceFieldNames 结构体字段在某个来源下的匹配方式
+type sourceFieldNames struct {
+	// tagged 该来源的 tag 声明的名称
+	tagged []string
+	// schema 没有该来源的 tag 时 schema tag 声明的名称(小写) -> 字段名
+	schema map[string]string
+	// others 只声明了其他来源的字段名
+	others []string
+}
+
+func (fs *sourceFieldNames) collect(t reflect.Type, tag string) {
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			fs.tagged = append(fs.tagged, name)
+			continue
+		}
+		var ft = f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			fs.collect(ft, tag)
+			continue
+		}
+		if name, _, _ := strings.Cut(f.Tag.Get("schema"), ","); name != "" {
+			if name == "-" {
+				fs.others = append(fs.others, f.Name)
+			} else {
+				if fs.schema == nil {
+					fs.schema = map[string]string{}
+				}
+				fs.schema[strings.ToLower(name)] = f.Name
+			}
+			continue
+		}
+		if slices.ContainsFunc(allSourceTags, func(other string) bool { return other != tag && f.Tag.Get(other) != "" }) {
+			fs.others = append(fs.others, f.Name)
+		}
+	}
+}
+
+// allSourceTags 可以声明字段来源的 tag，包括请求体中的表单
+var allSourceTags = []string{"path", "query", "form", "header", "cookie"}
+
+// sourceTags 请求体以外的来源使用的 tag
+var sourceTags = []string{"path", "query", "header", "cookie"}
+
+// keepSourceFields 记录 dst 中只声明了 sourceTags、没有 json tag 的字段，
+// 返回的函数把这些字段恢复为记录的值
+//
+// 	用于解析 json 请求体，避免 {"Tenant": "..."} 覆盖 header:"X-Tenant" 之类的字段
+func keepSourceFields(dst interface{}) (restore func()) {
+	if !isStructPtr(dst) {
+		return func() {}
+	}
+	var v = reflect.ValueOf(dst).Elem()
+	var paths = sourceFields(v.Type(), nil)
+	var saved = make([]reflect.Value, len(paths))
+	for i, p := range paths {
+		var ft = v.Type().FieldByIndex(p).Type
+		saved[i] = reflect.New(ft).Elem()
+		if f, err := v.FieldByIndexErr(p); err == nil {
+			saved[i].Set(f)
+		}
+	}
+	return func() {
+		for i, p := range paths {
+			if f, err := v.FieldByIndexErr(p); err == nil {
+				f.Set(saved[i])
+			}
+		}
+	}
+}
+
+// sourceFields 返回只声明了 sourceTags 的导出字段的索引，包括匿名嵌入的结构体中的字段
+func sourceFields(t reflect.Type, prefix []int) [][]int {
+	var paths [][]int
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		var index = append(slices.Clip(prefix), i)
+		var ft = f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if _, ok := f.Tag.Lookup("json"); ok {
+			continue
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			paths = append(paths, sourceFields(ft, index)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if slices.ContainsFunc(sourceTags, func(tag string) bool { return f.Tag.Get(tag) != "" }) {
+			paths = append(paths, index)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,102 @@
+package bind
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Translator 翻译字段校验错误的提示信息
+//
+// 	lang 为请求 Accept-Language 中的首选语言，如 zh-CN，可能为空
+type Translator interface {
+	Translate(lang string, fe FieldError) string
+}
+
+// TranslatorFunc 函数形式的 Translator
+type TranslatorFunc func(lang string, fe FieldError) string
+
+func (f TranslatorFunc) Translate(lang string, fe FieldError) string {
+	return f(lang, fe)
+}
+
+// MessageTranslator 基于消息模板的 Translator，结构为 语言 -> 校验规则 -> 模板
+//
+// 	模板中的 {field} {value} 会被替换为字段路径与被拒绝的值，{0} {1} ... 会被替换为规则的参数
+// 	语言先精确匹配(如 zh-CN)再匹配主语言(如 zh)，都没有时使用 fallback 语言，仍没有时保留原信息
+type MessageTranslator struct {
+	Messages map[string]map[string]string
+	Fallback string
+}
+
+func (m MessageTranslator) Translate(lang string, fe FieldError) string {
+	var base, _, _ = strings.Cut(lang, "-")
+	for _, l := range []string{lang, base, m.Fallback} {
+		if tmpl, ok := m.Messages[l][fe.Rule]; ok {
+			return formatMessage(tmpl, fe)
+		}
+	}
+	return fe.Message
+}
+
+func formatMessage(tmpl string, fe FieldError) string {
+	var replaces = []string{"{field}", fe.Field, "{value}", fmt.Sprint(fe.Value)}
+	for i, p := range fe.Params {
+		replaces = append(replaces, "{"+strconv.Itoa(i)+"}", p)
+	}
+	return strings.NewReplacer(replaces...).Replace(tmpl)
+}
+
+// DefaultTranslator bind.Should 使用的 Translator，为 nil 时使用 gookit/validate 的原始信息
+//
+// 	如需中英文提示可以设置为 BuiltinTranslator
+var DefaultTranslator Translator
+
+// BuiltinTranslator 内置常用校验规则的中英文提示
+var BuiltinTranslator = MessageTranslator{
+	Fallback: "en",
+	Messages: map[string]map[string]string{
+		"zh": {
+			"required": "{field}不能为空",
+			"min":      "{field}不能小于{0}",
+			"max":      "{field}不能大于{0}",
+			"min_len":  "{field}长度不能小于{0}",
+			"minLen":   "{field}长度不能小于{0}",
+			"max_len":  "{field}长度不能大于{0}",
+			"maxLen":   "{field}长度不能大于{0}",
+			"len":      "{field}长度必须为{0}",
+			"between":  "{field}必须在{0}到{1}之间",
+			"in":       "{field}的值不在允许的范围内",
+			"enum":     "{field}的值不在允许的范围内",
+			"email":    "{field}不是有效的邮箱地址",
+			"url":      "{field}不是有效的URL",
+			"int":      "{field}必须是整数",
+			"regex":    "{field}格式不正确",
+		},
+		"en": {
+			"required": "{field} is required",
+			"min":      "{field} must be at least {0}",
+			"max":      "{field} must be at most {0}",
+			"min_len":  "{field} length must be at least {0}",
+			"minLen":   "{field} length must be at least {0}",
+			"max_len":  "{field} length must be at most {0}",
+			"maxLen":   "{field} length must be at most {0}",
+			"len":      "{field} length must be {0}",
+			"between":  "{field} must be between {0} and {1}",
+			"in":       "{field} is not an allowed value",
+			"enum":     "{field} is not an allowed value",
+			"email":    "{field} is not a valid email address",
+			"url":      "{field} is not a valid URL",
+			"int":      "{field} must be an integer",
+			"regex":    "{field} has an invalid format",
+		},
+	},
+}
+
+// acceptLanguage 返回 Accept-Language 中的首选语言
+func acceptLanguage(r *http.Request) string {
+	var lang, _, _ = strings.Cut(r.Header.Get("Accept-Language"), ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	return strings.TrimSpace(lang)
+}
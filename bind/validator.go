@@ -0,0 +1,34 @@
+package bind
+
+import (
+	"github.com/gookit/validate"
+)
+
+// Validator 参数校验器
+//
+// 	Validate 返回 *ValidationError 时会经过 Translator 翻译后原样返回，
+// 	返回其他 error 时会被包装为 render.Error
+type Validator interface {
+	Validate(dst interface{}) error
+}
+
+// ValidatorFunc 函数形式的 Validator
+type ValidatorFunc func(dst interface{}) error
+
+func (f ValidatorFunc) Validate(dst interface{}) error {
+	return f(dst)
+}
+
+// DefaultValidator 基于 gookit/validate 的校验器，使用 validate tag
+var DefaultValidator Validator = gookitValidator{}
+
+type gookitValidator struct{}
+
+func (gookitValidator) Validate(dst interface{}) error {
+	var v = validate.Struct(dst)
+	v.StopOnError = false
+	if v.Validate() {
+		return nil
+	}
+	return newValidationError(dst, v.Errors)
+}
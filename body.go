@@ -0,0 +1,104 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/schema"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ErrUnsupportedMediaType Decode 不支持请求的 Content-Type
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// defaultMaxMemory 解析 multipart 表单时内存中最多保留的字节数
+const defaultMaxMemory = 32 << 20
+
+// formDecoder Decode 解析表单时使用的解码器，使用 form tag
+var formDecoder = func() *schema.Decoder {
+	var d = schema.NewDecoder()
+	d.SetAliasTag("form")
+	d.IgnoreUnknownKeys(true)
+	return d
+}()
+
+// cachedBody 已经被完整读取的请求体，可以重复读取
+type cachedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (c *cachedBody) Close() error {
+	return nil
+}
+
+// ReadBody 读取并缓存请求体
+//
+// 	读取之后 req.Body 会被替换为可重复读取的缓存，
+// 	所以中间件可以先行查看请求体，后续的 ReadBody、Request.Body 以及直接读取 req.Body 都不受影响
+// 	读取出错时(如超过 BodyLimit 的限制)不会缓存，返回读取到的部分与错误
+func ReadBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if c, ok := req.Body.(*cachedBody); ok {
+		c.Reader.Reset(c.data)
+		return c.data, nil
+	}
+
+	var data, err = io.ReadAll(req.Body)
+	if err != nil {
+		return data, err
+	}
+	_ = req.Body.Close()
+	req.Body = &cachedBody{Reader: bytes.NewReader(data), data: data}
+	return data, nil
+}
+
+// decodeBody 根据 Content-Type 把请求体解码到 dst
+func decodeBody(req *http.Request, dst interface{}) error {
+	var mediaType = "application/json"
+	var params map[string]string
+	if ct := req.Header.Get(HeaderContentType); ct != "" {
+		var err error
+		if mediaType, params, err = mime.ParseMediaType(ct); err != nil {
+			return err
+		}
+	}
+
+	var bs, err = ReadBody(req)
+	if err != nil {
+		return err
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return json.Unmarshal(bs, dst)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return xml.Unmarshal(bs, dst)
+	case mediaType == "application/msgpack" || mediaType == "application/x-msgpack" || mediaType == "application/vnd.msgpack":
+		return msgpack.Unmarshal(bs, dst)
+	case mediaType == "application/x-www-form-urlencoded":
+		var values, err = url.ParseQuery(string(bs))
+		if err != nil {
+			return err
+		}
+		return formDecoder.Decode(dst, values)
+	case mediaType == "multipart/form-data":
+		var form, err = multipart.NewReader(bytes.NewReader(bs), params["boundary"]).ReadForm(defaultMaxMemory)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = form.RemoveAll() }()
+		return formDecoder.Decode(dst, form.Value)
+	default:
+		return ErrUnsupportedMediaType
+	}
+}
@@ -0,0 +1,66 @@
+package seed
+
+import (
+	"context"
+	"net/http"
+
+	HRouter "github.com/julienschmidt/httprouter"
+)
+
+// Params 路由匹配到的路径参数，如 /user/:id 中的 id
+type Params = HRouter.Params
+
+// ParamsFromContext 从 context 中获取路径参数，没有时返回 nil
+//
+// 	路由在进入中间件之前就会把参数放入请求的 context 中
+// 	所以 HandleStd 注册的 handler 以及中间件都可以通过该方法读取
+func ParamsFromContext(ctx context.Context) Params {
+	return HRouter.ParamsFromContext(ctx)
+}
+
+// withRoute 把路由模板与路径参数放入请求的 context 中
+func withRoute(req *http.Request, pattern string, ps Params) *http.Request {
+	var ctx = req.Context()
+	if pattern != "" {
+		ctx = context.WithValue(ctx, routePatternCtxKey, pattern)
+	}
+	if len(ps) > 0 {
+		ctx = context.WithValue(ctx, HRouter.ParamsKey, ps)
+	}
+	if ctx == req.Context() {
+		return req
+	}
+	return req.WithContext(ctx)
+}
+
+// RoutePatternFromContext 从 context 中获取匹配到的路由模板，如 /user/:id
+//
+// 	分组的前缀已经包含在内，没有匹配到路由时返回空串
+func RoutePatternFromContext(ctx context.Context) string {
+	var pattern, _ = ctx.Value(routePatternCtxKey).(string)
+	return pattern
+}
+
+type contextKey string
+
+// routePatternCtxKey 路由模板在 context 中的 key
+const routePatternCtxKey contextKey = "__SeedRoutePattern__"
+
+// requestIDCtxKey 请求ID在 context 中的 key
+const requestIDCtxKey contextKey = "__SeedRequestID__"
+
+// WithRequestID 把请求ID放入 context 中
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, id)
+}
+
+// RequestIDFromContext 从 context 中获取请求ID，没有时返回空串
+//
+// 	请求ID一般由 middleware.RequestID 设置
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	var id, _ = ctx.Value(requestIDCtxKey).(string)
+	return id
+}
@@ -3,13 +3,19 @@ module github.com/ninthsoft/seed
 go 1.23.4
 
 require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/google/uuid v1.6.0
 	github.com/gookit/validate v1.5.4
 	github.com/gorilla/schema v1.4.1
 	github.com/julienschmidt/httprouter v1.3.0
+	github.com/klauspost/compress v1.18.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/gookit/filter v1.2.2 // indirect
 	github.com/gookit/goutil v0.6.18 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
@@ -3,6 +3,7 @@ package seed
 import (
 	"context"
 	"net/http"
+	"reflect"
 )
 
 // HandlerFunc 标准的HandlerFunc
@@ -13,23 +14,21 @@ func (h HandlerFunc) Handler() http.Handler {
 	var f http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
 		var request = NewRequest(r)
 		var response = h(r.Context(), request)
-		if response != nil {
+		if rr, ok := response.(requestResponse); ok {
+			_ = rr.serve(w, r)
+		} else if response != nil {
 			_ = response.WriteTo(w)
 		}
 	}
 	return f
 }
 
-var notFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	// Preflight request for OPTIONS request method
-	if r.Method == http.MethodOptions {
-		w.Header().Add("Access-Control-Allow-Origin", "*")
-		w.Header().Add("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD")
-		w.Header().Add("Access-Control-Allow-Headers", "*")
-		w.Header().Add("Access-Control-Allow-Credentials", "true")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
+// TypedHandler 记录了输入输出类型的 handler，如 typed.New 创建的 handler
+//
+// 	OpenAPI 等工具可以据此获取请求参数与响应数据的结构
+type TypedHandler interface {
+	http.Handler
+	IOTypes() (in, out reflect.Type)
+}
 
-	http.NotFoundHandler().ServeHTTP(w, r)
-})
+var notFound = http.NotFoundHandler()
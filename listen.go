@@ -0,0 +1,145 @@
+package seed
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ListenOption 监听地址的配置项，用于 MSeed.Listen 与 MSeed.ListenOn
+type ListenOption func(o *listenOptions)
+
+type listenOptions struct {
+	tlsConfig *tls.Config
+	certFile  string
+	keyFile   string
+	handler   http.Handler
+}
+
+// WithListenTLS 该地址以 HTTPS 提供服务
+//
+// 	cfg 中需要设置好证书(Certificates 或 GetCertificate)
+func WithListenTLS(cfg *tls.Config) ListenOption {
+	return func(o *listenOptions) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithListenCertFile 该地址以 HTTPS 提供服务，证书从文件读取，文件变化后自动重新加载
+func WithListenCertFile(certFile, keyFile string) ListenOption {
+	return func(o *listenOptions) {
+		o.certFile, o.keyFile = certFile, keyFile
+	}
+}
+
+// WithListenHandler 该地址使用单独的 handler 而不是路由本身
+//
+// 	如单独的管理端口:
+// 	s.Listen("tcp", "127.0.0.1:9090", seed.WithListenHandler(adminMux))
+func WithListenHandler(h http.Handler) ListenOption {
+	return func(o *listenOptions) {
+		o.handler = h
+	}
+}
+
+// WithHTTPSRedirect 该地址的所有请求都永久重定向(308)到 https
+//
+// 	port 为 https 的端口，为空或 443 时重定向的地址不带端口
+func WithHTTPSRedirect(port string) ListenOption {
+	return WithListenHandler(httpsRedirect(port))
+}
+
+// httpsRedirect 把请求重定向到同一 host 的 https 地址
+func httpsRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var host = req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+			// SplitHostPort 去掉了 IPv6 地址的方括号
+			host = "[" + host + "]"
+		}
+		var u = *req.URL
+		u.Scheme, u.Host = "https", host
+		http.Redirect(w, req, u.String(), http.StatusPermanentRedirect)
+	})
+}
+
+// binding 一个监听地址，ln 为空时启动时再按 network/addr 监听
+type binding struct {
+	network string
+	addr    string
+	ln      net.Listener
+	opts    listenOptions
+}
+
+// listen 打开监听
+//
+// 	unix socket 文件已存在且不是普通文件时(上次没有正常退出)会先删除
+func (b *binding) listen() (net.Listener, error) {
+	if b.ln != nil {
+		return b.ln, nil
+	}
+	if b.network == "unix" {
+		if info, err := os.Stat(b.addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(b.addr)
+		}
+	}
+	return net.Listen(b.network, b.addr)
+}
+
+// server 根据模板 base 创建该地址使用的 *http.Server
+func (b *binding) server(base *http.Server, handler http.Handler) *http.Server {
+	if b.opts.handler != nil {
+		handler = b.opts.handler
+	}
+	return &http.Server{
+		Addr:              b.addr,
+		Handler:           handler,
+		TLSConfig:         b.opts.tlsConfig,
+		ReadTimeout:       base.ReadTimeout,
+		ReadHeaderTimeout: base.ReadHeaderTimeout,
+		WriteTimeout:      base.WriteTimeout,
+		IdleTimeout:       base.IdleTimeout,
+		MaxHeaderBytes:    base.MaxHeaderBytes,
+		ErrorLog:          base.ErrorLog,
+		BaseContext:       base.BaseContext,
+		ConnContext:       base.ConnContext,
+	}
+}
+
+// serving 一个正在运行的 server 与其监听
+type serving struct {
+	srv *http.Server
+	ln  net.Listener
+	tls bool
+}
+
+// serving 打开监听并创建该地址对应的 serving
+func (b *binding) serving(base *http.Server, handler http.Handler) (*serving, error) {
+	var srv = b.server(base, handler)
+	if b.opts.certFile != "" {
+		var cfg, err = certFileTLSConfig(b.opts.tlsConfig, b.opts.certFile, b.opts.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = cfg
+	}
+	var ln, err = b.listen()
+	if err != nil {
+		return nil, err
+	}
+	return &serving{srv: srv, ln: ln, tls: srv.TLSConfig != nil}, nil
+}
+
+func (s *serving) serve() error {
+	if s.tls {
+		return s.srv.ServeTLS(s.ln, "", "")
+	}
+	return s.srv.Serve(s.ln)
+}
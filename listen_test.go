@@ -0,0 +1,110 @@
+package seed
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMultipleListeners(t *testing.T) {
+	var s = New()
+	s.HandleFunc(http.MethodGet, "/hello", func(ctx context.Context, req Request) Response {
+		return TextResponse(http.StatusOK, "app")
+	})
+
+	var listen = func() net.Listener {
+		var ln, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ln
+	}
+	var app, admin, redirect = listen(), listen(), listen()
+	var sock = filepath.Join(t.TempDir(), "app.sock")
+
+	s.ListenOn(app).
+		ListenOn(admin, WithListenHandler(s.ReadyHandler())).
+		ListenOn(redirect, WithHTTPSRedirect("8443")).
+		Listen("unix", sock)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var runErr = make(chan error, 1)
+	go func() { runErr <- s.RunContext(ctx) }()
+
+	var client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	var get = func(c *http.Client, url string) (*http.Response, string) {
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = c.Get(url); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var bs, _ = io.ReadAll(resp.Body)
+		return resp, string(bs)
+	}
+
+	if _, body := get(client, "http://"+app.Addr().String()+"/hello"); body != "app" {
+		t.Fatalf("app listener: want %q, got %q", "app", body)
+	}
+	if resp, body := get(client, "http://"+admin.Addr().String()+"/hello"); resp.StatusCode != http.StatusOK || body != "ok" {
+		t.Fatalf("admin listener: unexpected response %d %q", resp.StatusCode, body)
+	}
+	var req, _ = http.NewRequest(http.MethodGet, "http://"+redirect.Addr().String()+"/hello?a=1", nil)
+	req.Host = "example.com:8080"
+	if resp, err := http.DefaultTransport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	} else {
+		_ = resp.Body.Close()
+		if loc := resp.Header.Get(HeaderLocation); resp.StatusCode != http.StatusPermanentRedirect || loc != "https://example.com:8443/hello?a=1" {
+			t.Fatalf("redirect listener: unexpected response %d %q", resp.StatusCode, loc)
+		}
+	}
+
+	var unixClient = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	if _, body := get(unixClient, "http://unix/hello"); body != "app" {
+		t.Fatalf("unix listener: want %q, got %q", "app", body)
+	}
+
+	cancel()
+	if err := <-runErr; err != nil {
+		t.Fatalf("want nil on clean shutdown, got %v", err)
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	for _, tc := range []struct {
+		port, host, want string
+	}{
+		{"", "example.com", "https://example.com/a?b=1"},
+		{"443", "example.com:80", "https://example.com/a?b=1"},
+		{"8443", "example.com:80", "https://example.com:8443/a?b=1"},
+		{"", "[::1]:80", "https://[::1]/a?b=1"},
+		{"443", "[::1]", "https://[::1]/a?b=1"},
+		{"8443", "[::1]:80", "https://[::1]:8443/a?b=1"},
+	} {
+		var req = httptest.NewRequest(http.MethodGet, "/a?b=1", nil)
+		req.Host = tc.host
+		var w = httptest.NewRecorder()
+		httpsRedirect(tc.port).ServeHTTP(w, req)
+		if loc := w.Header().Get(HeaderLocation); w.Code != http.StatusPermanentRedirect || loc != tc.want {
+			t.Errorf("port %q host %q: want %s, got %d %s", tc.port, tc.host, tc.want, w.Code, loc)
+		}
+	}
+}
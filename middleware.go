@@ -20,10 +20,18 @@ type MiddlewareFuncs []MiddlewareFunc
 //
 // 执行顺序： filter1 -> filter2 -> filter3
 // 若 filter2 返回 false，调用将终止，即 filter3 不会被执行
+//
+// 传入的 ctx 会自动挂到 req 上，因此中间件通过 context.WithValue 追加的值
+// 无需重建 *http.Request 也能被后续的中间件以及业务 handler 读取到
 func (ms MiddlewareFuncs) Next(ctx context.Context, w http.ResponseWriter, req *http.Request) bool {
 	if len(ms) <= 0 {
 		return false
 	}
+	if ctx == nil {
+		ctx = req.Context()
+	} else if ctx != req.Context() {
+		req = req.WithContext(ctx)
+	}
 	var f = ms[0]
 	return f(ctx, w, req, ms[1:])
 }
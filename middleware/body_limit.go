@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+// BodyLimit is a middleware that limits the size of request bodies to n bytes.
+//
+// Requests whose Content-Length already exceeds n are answered with
+// render.ErrBodyTooLarge (413) right away. Otherwise the body is wrapped with
+// http.MaxBytesReader, so reading past n fails with *http.MaxBytesError,
+// which render.JSON and bind turn into the same 413 error.
+//
+// Like any middleware it can be applied to the whole router with Use, to a
+// Group or to a single route.
+func BodyLimit(n int64) seed.MiddlewareFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+		if req.ContentLength > n {
+			_ = render.JSON(ctx, nil, render.ErrBodyTooLarge).WriteTo(w)
+			return false
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			req.Body = http.MaxBytesReader(w, req.Body, n)
+		}
+		return next.Next(ctx, w, req)
+	}
+}
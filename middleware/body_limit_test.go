@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+func TestBodyLimit(t *testing.T) {
+	var r = seed.NewRouter()
+	r.HandleFunc(http.MethodPost, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		var dst map[string]interface{}
+		return render.JSON(ctx, nil, req.JsonUnmarshal(&dst))
+	}, BodyLimit(8))
+
+	var body = `{"name":"alice"}`
+	for name, req := range map[string]*http.Request{
+		"content length": httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)),
+		"chunked":        httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if name == "chunked" {
+				req.ContentLength = -1
+			}
+			var w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), `"code":4130`) {
+				t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
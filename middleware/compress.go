@@ -0,0 +1,348 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/ninthsoft/seed"
+)
+
+// CompressMinLength is the minimum response size in bytes worth compressing.
+// Smaller bodies are sent as is, since the encoding overhead outweighs the
+// savings.
+var CompressMinLength = 1024
+
+var defaultCompressibleContentTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/javascript",
+	"text/xml",
+	"application/javascript",
+	"application/x-javascript",
+	"application/json",
+	"application/xml",
+	"application/atom+xml",
+	"application/rss+xml",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+// encoder is implemented by the gzip, zlib and brotli writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Encodings supported by Compress, in order of preference when the client
+// weighs them equally.
+const (
+	encodingBrotli  = "br"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+var compressEncodings = []string{encodingBrotli, encodingGzip, encodingDeflate}
+
+type compressor struct {
+	types     map[string]struct{}
+	wildcards []string
+	pools     map[string]*sync.Pool
+}
+
+// Compress is a middleware that compresses response bodies with brotli, gzip
+// or deflate, whichever the client prefers according to Accept-Encoding.
+//
+// level is the gzip/deflate compression level (flate.DefaultCompression when
+// out of range), brotli uses the same level clamped to its own 0-11 range.
+// Only responses whose Content-Type matches types are compressed; a type may
+// end with "/*" to match a whole family, e.g. "text/*". When no types are
+// given a default set of text, JSON, XML and SVG types is used.
+//
+// Responses that already carry a Content-Encoding, have no body (1xx, 204,
+// 304, HEAD) or are smaller than CompressMinLength are left untouched. The
+// response writer keeps supporting http.Flusher, so streaming handlers still
+// work, and encoders are pooled across requests.
+func Compress(level int, types ...string) seed.MiddlewareFunc {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+	if len(types) == 0 {
+		types = defaultCompressibleContentTypes
+	}
+
+	var c = &compressor{types: map[string]struct{}{}, pools: map[string]*sync.Pool{}}
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if strings.HasSuffix(t, "/*") {
+			c.wildcards = append(c.wildcards, strings.TrimSuffix(t, "*"))
+		} else {
+			c.types[t] = struct{}{}
+		}
+	}
+
+	c.pools[encodingGzip] = &sync.Pool{New: func() interface{} {
+		var w, _ = gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+	// HTTP deflate is the zlib format (RFC 1950), not a raw flate stream
+	c.pools[encodingDeflate] = &sync.Pool{New: func() interface{} {
+		var w, _ = zlib.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+	var brLevel = level
+	switch {
+	case brLevel == flate.DefaultCompression:
+		brLevel = brotli.DefaultCompression
+	case brLevel < brotli.BestSpeed:
+		brLevel = brotli.BestSpeed
+	case brLevel > brotli.BestCompression:
+		brLevel = brotli.BestCompression
+	}
+	c.pools[encodingBrotli] = &sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, brLevel)
+	}}
+
+	return c.handle
+}
+
+func (c *compressor) handle(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	var encoding = negotiateEncoding(req.Header.Get("Accept-Encoding"))
+	if encoding == "" || req.Method == http.MethodHead || req.Header.Get("Upgrade") != "" {
+		return next.Next(ctx, w, req)
+	}
+
+	var cw = &compressWriter{ResponseWriter: w, c: c, encoding: encoding}
+	defer func() { _ = cw.Close() }()
+	return next.Next(ctx, cw, req)
+}
+
+// allowed reports whether a response of the given Content-Type should be
+// compressed.
+func (c *compressor) allowed(contentType string) bool {
+	var mediaType, _, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if _, ok := c.types[mediaType]; ok {
+		return true
+	}
+	for _, prefix := range c.wildcards {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks the supported encoding with the highest q-value in
+// an Accept-Encoding header, or "" when none is acceptable.
+func negotiateEncoding(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	var weights = map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		var name, params, _ = strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		var q = 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			var f, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		weights[name] = q
+	}
+
+	var best string
+	var bestQ float64
+	for _, enc := range compressEncodings {
+		var q, ok = weights[enc]
+		if !ok {
+			if q, ok = weights["*"]; !ok {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the body until it can decide whether
+// the response is worth compressing, then either streams it through a pooled
+// encoder or passes it through untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	c        *compressor
+	encoding string
+
+	wroteHeader bool
+	code        int
+	decided     bool
+	enc         encoder
+	buf         []byte
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	if code >= 100 && code < http.StatusOK {
+		// informational responses like 103 Early Hints precede the real one
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.wroteHeader = true
+	cw.code = code
+
+	var h = cw.Header()
+	switch {
+	case !bodyAllowed(code) || h.Get("Content-Encoding") != "":
+		cw.decide(false)
+	case h.Get(seed.HeaderContentType) != "" && !cw.c.allowed(h.Get(seed.HeaderContentType)):
+		cw.decide(false)
+	case h.Get(seed.HeaderContentLength) != "":
+		var n, err = strconv.Atoi(h.Get(seed.HeaderContentLength))
+		if err == nil && n < CompressMinLength {
+			cw.decide(false)
+		} else if err == nil && h.Get(seed.HeaderContentType) != "" {
+			cw.decide(true)
+		}
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= CompressMinLength {
+		if err := cw.decide(cw.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// compressible is called once enough of an undecided body is buffered, or
+// when the handler flushes or finishes.
+func (cw *compressWriter) compressible() bool {
+	if len(cw.buf) < CompressMinLength {
+		return false
+	}
+	var h = cw.Header()
+	if h.Get(seed.HeaderContentType) == "" {
+		h.Set(seed.HeaderContentType, http.DetectContentType(cw.buf))
+	}
+	return cw.c.allowed(h.Get(seed.HeaderContentType))
+}
+
+// decide writes the header, picking an encoder when compress is set, and
+// flushes whatever was buffered so far.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if compress {
+		var h = cw.Header()
+		h.Del(seed.HeaderContentLength)
+		h.Set("Content-Encoding", cw.encoding)
+		cw.enc = cw.c.pools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var buf = cw.buf
+	cw.buf = nil
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends any buffered data to the client. A body still shorter than
+// CompressMinLength is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		_ = cw.decide(cw.compressible())
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("middleware: compress writer does not support hijacking")
+}
+
+// Unwrap returns the original writer, for use with http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close finishes the response, flushing the encoder and returning it to its
+// pool.
+func (cw *compressWriter) Close() error {
+	if cw.wroteHeader && !cw.decided {
+		if err := cw.decide(cw.compressible()); err != nil {
+			return err
+		}
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	var err = cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	cw.c.pools[cw.encoding].Put(cw.enc)
+	cw.enc = nil
+	return err
+}
+
+var _ http.Flusher = &compressWriter{}
+var _ http.Hijacker = &compressWriter{}
+
+// bodyAllowed reports whether a response with the given status may have a body.
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
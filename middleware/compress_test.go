@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/ninthsoft/seed"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                         "",
+		"identity":                 "",
+		"gzip":                     "gzip",
+		"gzip, deflate, br":        "br",
+		"br;q=0.5, gzip":           "gzip",
+		"br;q=0, *":                "gzip",
+		"deflate;q=0.8, gzip;q=.1": "deflate",
+		"*;q=0":                    "",
+	} {
+		if got := negotiateEncoding(accept); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	var big = strings.Repeat(`{"name":"alice"},`, 200)
+	var r = seed.NewRouter()
+	r.Use(Compress(5))
+	r.HandleFunc(http.MethodGet, "/big", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.TextResponse(http.StatusOK, big)
+	})
+	r.HandleFunc(http.MethodGet, "/small", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.TextResponse(http.StatusOK, "ok")
+	})
+	r.HandleFunc(http.MethodGet, "/png", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.StreamResponse(http.StatusOK, "image/png", strings.NewReader(big))
+	})
+	r.HandleStd(http.MethodGet, "/stream", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(seed.HeaderContentType, "text/plain")
+		for i := 0; i < 3; i++ {
+			_, _ = io.WriteString(w, big)
+			w.(http.Flusher).Flush()
+		}
+	}))
+
+	var get = func(path, accept string) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", accept)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var w = get("/big", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("want Vary Accept-Encoding, got %q", w.Header().Get("Vary"))
+	}
+	var zr, err = gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, _ := io.ReadAll(zr); string(bs) != big {
+		t.Fatalf("gzip body mismatch")
+	}
+
+	w = get("/big", "br")
+	if w.Header().Get("Content-Encoding") != "br" {
+		t.Fatalf("want br, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if bs, _ := io.ReadAll(brotli.NewReader(w.Body)); string(bs) != big {
+		t.Fatalf("brotli body mismatch")
+	}
+
+	w = get("/big", "deflate")
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("want deflate, got %q", w.Header().Get("Content-Encoding"))
+	}
+	zlr, err := zlib.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, _ := io.ReadAll(zlr); string(bs) != big {
+		t.Fatalf("deflate body mismatch")
+	}
+
+	for _, path := range []string{"/small", "/png"} {
+		w = get(path, "gzip")
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s: want uncompressed, got headers %v", path, w.Header())
+		}
+	}
+
+	w = get("/stream", "gzip")
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want flushed gzip stream, got flushed=%v headers %v", w.Flushed, w.Header())
+	}
+	zr, _ = gzip.NewReader(w.Body)
+	if bs, _ := io.ReadAll(zr); string(bs) != strings.Repeat(big, 3) {
+		t.Fatalf("stream body mismatch")
+	}
+
+	w = get("/big", "")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != big {
+		t.Fatalf("want identity response")
+	}
+}
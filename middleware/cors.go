@@ -3,15 +3,259 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/ninthsoft/seed"
 )
 
+// CORSOptions configures the CORSWithOptions middleware.
+type CORSOptions struct {
+	// AllowedOrigins is a list of origins a cross-domain request can be
+	// executed from. An origin may be an exact match ("https://example.com"),
+	// contain a single wildcard ("https://*.example.com") that matches exactly
+	// one non-empty subdomain label, or be "*" to allow any origin. Default value is ["*"] when AllowOriginFunc is nil.
+	AllowedOrigins []string
+
+	// AllowOriginFunc is a custom function to validate the origin. It is
+	// consulted after AllowedOrigins and can accept origins it rejected.
+	AllowOriginFunc func(r *http.Request, origin string) bool
+
+	// AllowedMethods is a list of methods the client is allowed to use with
+	// cross-domain requests. Default value is simple methods plus PUT, PATCH
+	// and DELETE.
+	AllowedMethods []string
+
+	// AllowedHeaders is a list of non simple headers the client is allowed to
+	// use with cross-domain requests. "*" allows any header the client asks
+	// for. Default value is ["*"].
+	AllowedHeaders []string
+
+	// ExposedHeaders indicates which headers are safe to expose to the API of
+	// a CORS API specification.
+	ExposedHeaders []string
+
+	// AllowCredentials indicates whether the request can include user
+	// credentials like cookies. When set, the matched origin is echoed back
+	// instead of "*", as browsers reject a wildcard with credentials.
+	AllowCredentials bool
+
+	// MaxAge indicates how long (in seconds) the results of a preflight
+	// request can be cached. Zero omits the header.
+	MaxAge int
+
+	// OptionsPassthrough instructs preflight to let other handlers handle
+	// the OPTIONS request after the CORS headers are set.
+	OptionsPassthrough bool
+
+	// OptionsSuccessStatus is the status code of a short-circuited
+	// preflight response. Default value is http.StatusNoContent.
+	OptionsSuccessStatus int
+}
+
+type cors struct {
+	allowAll         bool
+	origins          []string
+	wildcards        [][2]string
+	originFunc       func(r *http.Request, origin string) bool
+	methods          []string
+	allowAllHeaders  bool
+	headers          []string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+	passthrough      bool
+	successStatus    int
+}
+
+var defaultCORS = CORSWithOptions(CORSOptions{})
+
+// CORS is a middleware that allows cross-domain requests from any origin,
+// without credentials. Use CORSWithOptions for anything more specific.
+//
+// CORS should be registered on the root router through Use, so it also sees
+// the preflight requests the router answers automatically.
 func CORS(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
-	w.Header().Add("Access-Control-Allow-Origin", "*")
-	w.Header().Add("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD")
-	w.Header().Add("Access-Control-Allow-Headers", "*")
-	w.Header().Add("Access-Control-Allow-Credentials", "true")
+	return defaultCORS(ctx, w, req, next)
+}
+
+// CORSWithOptions returns a CORS middleware configured by opts.
+//
+// Preflight requests (OPTIONS with Origin and Access-Control-Request-Method
+// headers) are answered directly unless OptionsPassthrough is set. Like CORS,
+// it should be registered on the root router through Use.
+func CORSWithOptions(opts CORSOptions) seed.MiddlewareFunc {
+	var c = &cors{
+		originFunc:       opts.AllowOriginFunc,
+		allowCredentials: opts.AllowCredentials,
+		passthrough:      opts.OptionsPassthrough,
+		successStatus:    opts.OptionsSuccessStatus,
+	}
+	if c.successStatus == 0 {
+		c.successStatus = http.StatusNoContent
+	}
+
+	var origins = opts.AllowedOrigins
+	if len(origins) == 0 && c.originFunc == nil {
+		origins = []string{"*"}
+	}
+	for _, o := range origins {
+		o = strings.ToLower(o)
+		if o == "*" {
+			c.allowAll = true
+			break
+		}
+		if i := strings.IndexByte(o, '*'); i >= 0 {
+			c.wildcards = append(c.wildcards, [2]string{o[:i], o[i+1:]})
+		} else {
+			c.origins = append(c.origins, o)
+		}
+	}
+
+	var methods = opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodPost, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	for _, m := range methods {
+		c.methods = append(c.methods, strings.ToUpper(m))
+	}
+
+	var headers = opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"*"}
+	}
+	for _, h := range headers {
+		if h == "*" {
+			c.allowAllHeaders = true
+			break
+		}
+		c.headers = append(c.headers, http.CanonicalHeaderKey(h))
+	}
+
+	var exposed = make([]string, 0, len(opts.ExposedHeaders))
+	for _, h := range opts.ExposedHeaders {
+		exposed = append(exposed, http.CanonicalHeaderKey(h))
+	}
+	c.exposedHeaders = strings.Join(exposed, ", ")
 
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(opts.MaxAge)
+	}
+
+	return c.handle
+}
+
+func (c *cors) handle(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+	if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+		c.preflight(w, req)
+		if c.passthrough {
+			return next.Next(ctx, w, req)
+		}
+		w.WriteHeader(c.successStatus)
+		return false
+	}
+	c.actual(w, req)
 	return next.Next(ctx, w, req)
 }
+
+func (c *cors) preflight(w http.ResponseWriter, req *http.Request) {
+	var h = w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	var origin = req.Header.Get("Origin")
+	if origin == "" || !c.originAllowed(req, origin) {
+		return
+	}
+	var method = strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+	if !slices.Contains(c.methods, method) {
+		return
+	}
+	var reqHeaders = parseHeaderList(req.Header.Get("Access-Control-Request-Headers"))
+	if !c.headersAllowed(reqHeaders) {
+		return
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", method)
+	if len(reqHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+	}
+	if c.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+}
+
+func (c *cors) actual(w http.ResponseWriter, req *http.Request) {
+	var h = w.Header()
+	var origin = req.Header.Get("Origin")
+	if !c.allowAll || c.allowCredentials {
+		h.Add("Vary", "Origin")
+	}
+	if origin == "" || !c.originAllowed(req, origin) {
+		return
+	}
+
+	c.setOrigin(h, origin)
+	if c.exposedHeaders != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+	if c.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// setOrigin writes Access-Control-Allow-Origin, echoing the request origin
+// unless any origin is allowed without credentials.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.allowAll && !c.allowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+}
+
+func (c *cors) originAllowed(req *http.Request, origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	var o = strings.ToLower(origin)
+	if slices.Contains(c.origins, o) {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if len(o) > len(w[0])+len(w[1]) && strings.HasPrefix(o, w[0]) && strings.HasSuffix(o, w[1]) &&
+			!strings.Contains(o[len(w[0]):len(o)-len(w[1])], ".") {
+			return true
+		}
+	}
+	return c.originFunc != nil && c.originFunc(req, origin)
+}
+
+func (c *cors) headersAllowed(headers []string) bool {
+	if c.allowAllHeaders || len(headers) == 0 {
+		return true
+	}
+	for _, h := range headers {
+		if !slices.Contains(c.headers, h) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHeaderList splits a comma separated header list into canonical keys.
+func parseHeaderList(list string) []string {
+	var headers []string
+	for _, h := range strings.Split(list, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	return headers
+}
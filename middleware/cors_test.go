@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ninthsoft/seed"
+)
+
+func newCORSRouter(opts CORSOptions) seed.Router {
+	var r = seed.NewRouter()
+	r.Use(CORSWithOptions(opts))
+	r.HandleFunc(http.MethodPost, "/api", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.NopResponse(http.StatusOK)
+	})
+	return r
+}
+
+func TestCORSPreflightShortCircuits(t *testing.T) {
+	var r = newCORSRouter(CORSOptions{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	var req = httptest.NewRequest(http.MethodOptions, "/api", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("want status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	for k, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Methods":     "POST",
+		"Access-Control-Allow-Headers":     "Content-Type",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := w.Header().Get(k); got != want {
+			t.Errorf("want %s %q, got %q", k, want, got)
+		}
+	}
+}
+
+func TestCORSRejectsUnknownOrigin(t *testing.T) {
+	var r = newCORSRouter(CORSOptions{AllowedOrigins: []string{"https://example.com"}})
+
+	var req = httptest.NewRequest(http.MethodPost, "/api", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("want no Access-Control-Allow-Origin, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("want Vary %q, got %q", "Origin", got)
+	}
+}
+
+func TestCORSWildcardMatchesOneLabel(t *testing.T) {
+	var r = newCORSRouter(CORSOptions{AllowedOrigins: []string{"https://*.example.com"}})
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com":   true,
+		"https://.example.com":      false,
+		"https://a.b.example.com":   false,
+		"https://example.com":       false,
+		"https://evil.example.com.": false,
+	} {
+		var req = httptest.NewRequest(http.MethodPost, "/api", nil)
+		req.Header.Set("Origin", origin)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); (got == origin) != allowed {
+			t.Errorf("%s: want allowed=%v, got Access-Control-Allow-Origin %q", origin, allowed, got)
+		}
+	}
+}
+
+func TestCORSDefaultNeverCombinesWildcardWithCredentials(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(CORS)
+	r.HandleFunc(http.MethodGet, "/api", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.NopResponse(http.StatusOK)
+	})
+
+	var req = httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("Origin", "https://example.com")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("want Access-Control-Allow-Origin %q, got %q", "*", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("want no Access-Control-Allow-Credentials, got %q", got)
+	}
+}
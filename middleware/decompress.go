@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+// ErrUnsupportedEncoding is rendered by Decompress when the request body uses
+// a Content-Encoding it cannot decode.
+var ErrUnsupportedEncoding = render.NewError(http.StatusText(http.StatusUnsupportedMediaType), 4150).WithStatus(http.StatusUnsupportedMediaType)
+
+// ErrMalformedEncoding is rendered by Decompress when the request body does not
+// match its Content-Encoding.
+var ErrMalformedEncoding = render.NewError("malformed request body encoding", 4000).WithStatus(http.StatusBadRequest)
+
+// Decompress is a middleware that transparently decodes request bodies sent
+// with a gzip, deflate, br or zstd Content-Encoding. Several encodings, e.g.
+// "gzip, br", are undone in reverse order.
+//
+// The decoded body is limited to limit bytes to guard against zip bombs:
+// reading past it fails with *http.MaxBytesError, which render.JSON and bind
+// turn into render.ErrBodyTooLarge (413). Unsupported encodings, or more than
+// maxContentEncodings of them, are answered with ErrUnsupportedEncoding (415)
+// before the handler runs.
+//
+// Content-Encoding and Content-Length are removed from the request, so
+// Request.Body, Request.Decode and bind see the plain body. Register it before
+// anything that reads the body, and after BodyLimit when the size on the wire
+// should be limited too.
+func Decompress(limit int64) seed.MiddlewareFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+		var encodings = parseContentEncoding(req.Header.Get("Content-Encoding"))
+		if len(encodings) == 0 || req.Body == nil || req.Body == http.NoBody {
+			return next.Next(ctx, w, req)
+		}
+		if len(encodings) > maxContentEncodings {
+			_ = render.JSON(ctx, nil, ErrUnsupportedEncoding).WriteTo(w)
+			return false
+		}
+		for _, enc := range encodings {
+			if _, ok := decoders[enc]; !ok {
+				_ = render.JSON(ctx, nil, ErrUnsupportedEncoding).WriteTo(w)
+				return false
+			}
+		}
+
+		var body = &decodedBody{Reader: req.Body, closers: []io.Closer{req.Body}}
+		for i := len(encodings) - 1; i >= 0; i-- {
+			var rc, err = decoders[encodings[i]](body.Reader, limit)
+			if err != nil {
+				_ = body.Close()
+				_ = render.JSON(ctx, nil, ErrMalformedEncoding).WriteTo(w)
+				return false
+			}
+			body.Reader = rc
+			body.closers = append(body.closers, rc)
+		}
+
+		req.Body = http.MaxBytesReader(w, body, limit)
+		req.Header.Del("Content-Encoding")
+		req.Header.Del(seed.HeaderContentLength)
+		req.ContentLength = -1
+		return next.Next(ctx, w, req)
+	}
+}
+
+// maxContentEncodings is the most stacked encodings Decompress undoes, each
+// layer costs a decoder and its buffers.
+const maxContentEncodings = 2
+
+// decoders maps a Content-Encoding to a constructor of its decoding reader.
+// limit is the most decoded bytes the caller will read, decoders that buffer
+// according to the stream header use it to cap their memory.
+var decoders = map[string]func(r io.Reader, limit int64) (io.ReadCloser, error){
+	"gzip":   gzipDecoder,
+	"x-gzip": gzipDecoder,
+	"deflate": func(r io.Reader, _ int64) (io.ReadCloser, error) {
+		// deflate is meant to be zlib wrapped, but raw streams are common
+		var br = bufio.NewReader(r)
+		if hdr, err := br.Peek(2); err == nil && isZlibHeader(hdr) {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	},
+	"br": func(r io.Reader, _ int64) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": zstdDecoder,
+}
+
+func gzipDecoder(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// zstdDecoder refuses frames whose window or declared size exceeds limit, so
+// a tiny frame cannot make the decoder allocate a huge window up front.
+func zstdDecoder(r io.Reader, limit int64) (io.ReadCloser, error) {
+	var window = min(max(limit, zstd.MinWindowSize), zstd.MaxWindowSize)
+	var d, err = zstd.NewReader(r,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(uint64(window)),
+		zstd.WithDecoderMaxMemory(uint64(max(limit+1, window))),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdBody{ReadCloser: d.IOReadCloser(), limit: limit}, nil
+}
+
+// zstdBody reports the size limits of the decoder as *http.MaxBytesError, so
+// they are answered with 413 like any other oversized body.
+type zstdBody struct {
+	io.ReadCloser
+	limit int64
+}
+
+func (b *zstdBody) Read(p []byte) (int, error) {
+	var n, err = b.ReadCloser.Read(p)
+	if errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		err = &http.MaxBytesError{Limit: b.limit}
+	}
+	return n, err
+}
+
+func isZlibHeader(h []byte) bool {
+	return h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0
+}
+
+// parseContentEncoding lists the encodings applied to a body in order,
+// dropping identity.
+func parseContentEncoding(header string) []string {
+	var encodings []string
+	for _, enc := range strings.Split(header, ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc != "" && enc != "identity" {
+			encodings = append(encodings, enc)
+		}
+	}
+	return encodings
+}
+
+// decodedBody closes every decoder along with the original body.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if e := b.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+func gzipBytes(s string) []byte {
+	var buf bytes.Buffer
+	var zw = gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(s))
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func zstdBytes(s string) []byte {
+	var enc, _ = zstd.NewWriter(nil)
+	return enc.EncodeAll([]byte(s), nil)
+}
+
+func TestDecompress(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(Decompress(64))
+	r.HandleFunc(http.MethodPost, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		var dst struct {
+			Name string `json:"name"`
+		}
+		if err := req.JsonUnmarshal(&dst); err != nil {
+			return render.JSON(ctx, nil, err)
+		}
+		// the decoded body is cached and can be read again
+		var bs, _ = req.Body()
+		return seed.TextResponse(http.StatusOK, dst.Name+" "+string(bs))
+	})
+
+	var post = func(encoding string, body []byte) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", encoding)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var body = `{"name":"alice"}`
+	for encoding, data := range map[string][]byte{
+		"gzip":     gzipBytes(body),
+		"zstd":     zstdBytes(body),
+		"identity": []byte(body),
+	} {
+		if w := post(encoding, data); w.Code != http.StatusOK || w.Body.String() != "alice "+body {
+			t.Fatalf("%s: unexpected response %d %s", encoding, w.Code, w.Body.String())
+		}
+	}
+
+	var twice = gzipBytes(string(zstdBytes(body)))
+	if w := post("zstd, gzip", twice); w.Code != http.StatusOK || w.Body.String() != "alice "+body {
+		t.Fatalf("stacked: unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	for _, encoding := range []string{"compress", "gzip, gzip, gzip"} {
+		if w := post(encoding, []byte(body)); w.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("%s: want status %d, got %d", encoding, http.StatusUnsupportedMediaType, w.Code)
+		}
+	}
+	if w := post("gzip", []byte(body)); w.Code != http.StatusBadRequest {
+		t.Fatalf("want status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var bomb = `{"name":"` + strings.Repeat("a", 1<<20) + `"}`
+	if w := post("gzip", gzipBytes(bomb)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
+
+// zstdStream compresses n zero bytes as a streaming frame, whose header
+// declares the window size instead of the content size.
+func zstdStream(n int, window int) []byte {
+	var buf bytes.Buffer
+	var enc, _ = zstd.NewWriter(&buf, zstd.WithWindowSize(window))
+	_, _ = enc.Write(make([]byte, n))
+	_ = enc.Close()
+	return buf.Bytes()
+}
+
+func TestDecompressBombs(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(Decompress(1 << 10))
+	r.HandleFunc(http.MethodPost, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		var _, err = req.Body()
+		return render.JSON(ctx, nil, err)
+	})
+
+	for name, tc := range map[string]struct {
+		encoding string
+		body     []byte
+	}{
+		"gzip":          {"gzip", gzipBytes(strings.Repeat("\x00", 16<<20))},
+		"zstd":          {"zstd", zstdBytes(strings.Repeat("\x00", 32<<20))},
+		"zstd window":   {"zstd", zstdStream(32<<20, 64<<20)},
+		"gzip and zstd": {"zstd, gzip", gzipBytes(string(zstdStream(32<<20, 64<<20)))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if len(tc.body) > 64<<10 {
+				t.Fatalf("bomb is not small: %d bytes", len(tc.body))
+			}
+			var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+			req.Header.Set("Content-Encoding", tc.encoding)
+			var w = httptest.NewRecorder()
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			r.ServeHTTP(w, req)
+			runtime.ReadMemStats(&after)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("want status %d, got %d %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+			}
+			if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 8<<20 {
+				t.Fatalf("decoding allocated %d bytes for a %d byte limit", alloc, 1<<10)
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"runtime"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ninthsoft/seed"
@@ -18,6 +21,10 @@ var (
 	// LogEntryCtxKey is the context.Context key to store the request log entry.
 	LogEntryCtxKey ContextKey = "__SeedLogEntry__"
 
+	// LogFieldsCtxKey is the context.Context key to store the extra fields of
+	// the request log entry.
+	LogFieldsCtxKey ContextKey = "__SeedLogFields__"
+
 	MLogFormatter = &DefaultLogFormatter{}
 )
 
@@ -34,28 +41,33 @@ var (
 //	r.Use(middleware.Logger)        // <--<< Logger should come before Recoverer
 //	r.Use(middleware.Recoverer)
 func Logger(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
-	var entry = MLogFormatter.NewLogEntry(req)
-	var ww = NewWrapResponseWriter(w, req.ProtoMajor)
-	var t1 = time.Now()
-	defer func() {
-		entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), nil)
-	}()
-	return next.Next(ctx, ww, req)
+	return logRequest(MLogFormatter, ctx, w, req, next)
 }
 
 // RequestLogger returns a logger handler using a custom LogFormatter.
 func RequestLogger(f LogFormatter) seed.MiddlewareFunc {
 	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
-		var entry = f.NewLogEntry(req)
-		var ww = NewWrapResponseWriter(w, req.ProtoMajor)
-		var t1 = time.Now()
-		defer func() {
-			entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), nil)
-		}()
-		return next.Next(ctx, ww, req)
+		return logRequest(f, ctx, w, req, next)
 	}
 }
 
+// logRequest creates the LogEntry, stores it (and the extra log fields) in the
+// context forwarded downstream and writes it once the request completes.
+func logRequest(f LogFormatter, ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+	var fields = &logFields{}
+	req = req.WithContext(context.WithValue(ctx, LogFieldsCtxKey, fields))
+
+	var entry = f.NewLogEntry(req)
+	req = WithLogEntry(req, entry)
+
+	var ww = NewWrapResponseWriter(w, req.ProtoMajor)
+	var t1 = time.Now()
+	defer func() {
+		entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), fields.extra())
+	}()
+	return next.Next(req.Context(), ww, req)
+}
+
 // LogFormatter initiates the beginning of a new LogEntry per request.
 // See DefaultLogFormatter for an example implementation.
 type LogFormatter interface {
@@ -64,6 +76,9 @@ type LogFormatter interface {
 
 // LogEntry records the final log when a request completes.
 // See defaultLogEntry for an example implementation.
+//
+// When used through Logger or RequestLogger, extra is either nil or the
+// LogFields set with LogEntrySetField during the request.
 type LogEntry interface {
 	Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{})
 	Panic(v interface{}, stack []byte)
@@ -81,6 +96,48 @@ func WithLogEntry(r *http.Request, entry LogEntry) *http.Request {
 	return r
 }
 
+// LogFields are the extra fields attached to a request log entry.
+type LogFields map[string]interface{}
+
+type logFields struct {
+	mu     sync.Mutex
+	fields LogFields
+}
+
+func (l *logFields) set(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fields == nil {
+		l.fields = LogFields{}
+	}
+	l.fields[key] = value
+}
+
+// extra returns a copy of the fields, or nil if none was set.
+func (l *logFields) extra() interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.fields) == 0 {
+		return nil
+	}
+	return maps.Clone(l.fields)
+}
+
+// LogEntrySetField attaches a field to the request log entry, it is passed to
+// LogEntry.Write as part of extra. It is a no-op if no Logger is in the chain.
+func LogEntrySetField(ctx context.Context, key string, value interface{}) {
+	if fields, ok := ctx.Value(LogFieldsCtxKey).(*logFields); ok {
+		fields.set(key, value)
+	}
+}
+
+// LogEntrySetFields attaches several fields to the request log entry.
+func LogEntrySetFields(ctx context.Context, fields LogFields) {
+	for k, v := range fields {
+		LogEntrySetField(ctx, k, v)
+	}
+}
+
 // LoggerInterface accepts printing to stdlib logger or compatible logger.
 type LoggerInterface interface {
 	Print(v ...interface{})
@@ -102,10 +159,10 @@ func (l *DefaultLogFormatter) NewLogEntry(r *http.Request) LogEntry {
 		useColor:            useColor,
 	}
 
-	//reqID := GetReqID(r.Context())
-	//if reqID != "" {
-	//	cW(entry.buf, useColor, nYellow, "[%s] ", reqID)
-	//}
+	reqID := GetReqID(r.Context())
+	if reqID != "" {
+		cW(entry.buf, useColor, nYellow, "[%s] ", reqID)
+	}
 
 	cW(entry.buf, useColor, nCyan, "\"")
 	cW(entry.buf, useColor, bMagenta, "%s ", r.Method)
@@ -155,11 +212,18 @@ func (l *defaultLogEntry) Write(status, bytes int, header http.Header, elapsed t
 		cW(l.buf, l.useColor, nRed, "%s", elapsed)
 	}
 
+	if fields, ok := extra.(LogFields); ok {
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			cW(l.buf, l.useColor, nCyan, " %s=", k)
+			cW(l.buf, false, nCyan, "%v", fields[k])
+		}
+	}
+
 	l.Logger.Print(l.buf.String())
 }
 
 func (l *defaultLogEntry) Panic(v interface{}, stack []byte) {
-	PrintPrettyStack(v)
+	printPrettyStack(v, GetReqID(l.request.Context()))
 }
 
 func init() {
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+type recordFormatter struct {
+	entry *recordEntry
+}
+
+func (f *recordFormatter) NewLogEntry(r *http.Request) LogEntry {
+	f.entry = &recordEntry{}
+	return f.entry
+}
+
+type recordEntry struct {
+	status   int
+	extra    interface{}
+	panicked interface{}
+}
+
+func (e *recordEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
+	e.status = status
+	e.extra = extra
+}
+
+func (e *recordEntry) Panic(v interface{}, stack []byte) {
+	e.panicked = v
+}
+
+func TestRequestLoggerEntryReachesRecoverer(t *testing.T) {
+	var f = &recordFormatter{}
+	var r = seed.NewRouter()
+	r.Use(RequestLogger(f), Recoverer)
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		panic("boom")
+	})
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if f.entry.panicked != "boom" {
+		t.Fatalf("want Panic to be called on the in-context entry, got %v", f.entry.panicked)
+	}
+	if f.entry.status != http.StatusInternalServerError {
+		t.Fatalf("want logged status %d, got %d", http.StatusInternalServerError, f.entry.status)
+	}
+}
+
+func TestLogEntrySetField(t *testing.T) {
+	var f = &recordFormatter{}
+	var r = seed.NewRouter()
+	r.Use(RequestLogger(f))
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		LogEntrySetField(ctx, "user", "alice")
+		LogEntrySetFields(ctx, LogFields{"tenant": "acme"})
+		return seed.NopResponse(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var fields, ok = f.entry.extra.(LogFields)
+	if !ok || fields["user"] != "alice" || fields["tenant"] != "acme" {
+		t.Fatalf("want extra fields to reach Write, got %#v", f.entry.extra)
+	}
+}
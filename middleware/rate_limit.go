@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+// ErrTooManyRequests is the error rendered by the default rate limit response.
+var ErrTooManyRequests = render.NewError(http.StatusText(http.StatusTooManyRequests), 4290).WithStatus(http.StatusTooManyRequests)
+
+// RateLimitAlgorithm selects how a RateLimitStore counts requests.
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket refills Limit tokens per Window, up to Burst tokens, and
+	// each request takes one. Short bursts are allowed, the long term rate is
+	// Limit per Window.
+	TokenBucket RateLimitAlgorithm = iota
+
+	// SlidingWindow allows Limit requests in any Window, estimated from the
+	// counts of the current and the previous fixed window.
+	SlidingWindow
+)
+
+// RateLimitRule is the limit a RateLimitStore enforces for a key.
+type RateLimitRule struct {
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	// Burst is the bucket capacity of TokenBucket, Limit when zero.
+	Burst int
+}
+
+// RateLimitResult is the outcome of RateLimitStore.Allow.
+type RateLimitResult struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Limit is the number of requests allowed per window (or the burst).
+	Limit int
+	// Remaining is the number of requests left before being limited.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed, set
+	// when Allowed is false.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the rate limit state of every key. Implementations
+// must be safe for concurrent use; MemoryStore is the in-process one, a
+// shared store (e.g. Redis) is needed when running several instances.
+type RateLimitStore interface {
+	// Allow counts one request for key under rule.
+	Allow(ctx context.Context, key string, rule RateLimitRule) (RateLimitResult, error)
+}
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	// Limit is the number of requests allowed per Window.
+	Limit int
+
+	// Window is the period Limit applies to. Default value is one minute.
+	Window time.Duration
+
+	// Burst is the bucket capacity of the TokenBucket algorithm. Default value
+	// is Limit.
+	Burst int
+
+	// Algorithm is the counting algorithm. Default value is TokenBucket.
+	Algorithm RateLimitAlgorithm
+
+	// KeyFunc returns the key requests are counted by. Requests with an empty
+	// key are not limited. Default value is KeyByIP.
+	KeyFunc func(req *http.Request) string
+
+	// Store keeps the counters. Default value is a new MemoryStore.
+	Store RateLimitStore
+
+	// Response builds the response sent to limited requests. Default value
+	// renders ErrTooManyRequests through render.JSON.
+	Response func(ctx context.Context, req *http.Request, res RateLimitResult) seed.Response
+}
+
+// RateLimit is a middleware that limits how often each client may call the
+// routes it is applied to, typically a Group or a single route such as login.
+//
+// Every response carries RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers; limited requests additionally get Retry-After and
+// a 429 Too Many Requests response. When the store fails the request is let
+// through rather than locking every client out.
+func RateLimit(opts RateLimitOptions) seed.MiddlewareFunc {
+	if opts.Limit <= 0 {
+		panic("middleware: rate limit must be positive")
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = KeyByIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.Response == nil {
+		opts.Response = func(ctx context.Context, req *http.Request, res RateLimitResult) seed.Response {
+			return render.JSON(ctx, nil, ErrTooManyRequests)
+		}
+	}
+	var rule = RateLimitRule{Algorithm: opts.Algorithm, Limit: opts.Limit, Window: opts.Window, Burst: opts.Burst}
+
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+		var key = opts.KeyFunc(req)
+		if key == "" {
+			return next.Next(ctx, w, req)
+		}
+		var res, err = opts.Store.Allow(ctx, key, rule)
+		if err != nil {
+			return next.Next(ctx, w, req)
+		}
+
+		var h = w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		if res.Allowed {
+			return next.Next(ctx, w, req)
+		}
+
+		h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+		_ = opts.Response(ctx, req, res).WriteTo(w)
+		return false
+	}
+}
+
+// KeyByIP counts requests by the host of RemoteAddr. Forwarding headers are
+// never read, as clients could pick a fresh key for every request; behind a
+// proxy, run RealIPWithOptions before RateLimit so that RemoteAddr holds the
+// client address.
+func KeyByIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// KeyByHeader returns a key function counting requests by the value of the
+// given header, e.g. an API key. Requests without the header are not limited.
+func KeyByHeader(name string) func(req *http.Request) string {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// KeyByRouteAndIP counts requests by matched route pattern and client IP, so
+// that one limiter on a Group gives every route its own quota.
+func KeyByRouteAndIP(req *http.Request) string {
+	var route = seed.RoutePatternFromContext(req.Context())
+	if route == "" {
+		route = req.URL.Path
+	}
+	return route + "|" + KeyByIP(req)
+}
+
+// ceilSeconds formats d as a whole number of seconds, rounding up.
+func ceilSeconds(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	memoryStoreShards = 32
+	// memoryStoreSweep is how often a shard drops its expired keys.
+	memoryStoreSweep = time.Minute
+)
+
+// MemoryStore is an in-process RateLimitStore. Keys are spread over shards
+// with their own lock, and keys idle long enough for their quota to be fully
+// restored are evicted lazily while the store is used.
+type MemoryStore struct {
+	shards [memoryStoreShards]memoryShard
+	now    func() time.Time
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateEntry
+	lastSweep time.Time
+}
+
+// rateEntry is the state of one key, the fields used depend on the algorithm.
+type rateEntry struct {
+	expires time.Time
+
+	// TokenBucket
+	tokens float64
+	last   time.Time
+
+	// SlidingWindow
+	start time.Time
+	prev  int
+	curr  int
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	var s = &MemoryStore{now: time.Now}
+	for i := range s.shards {
+		s.shards[i].entries = map[string]*rateEntry{}
+	}
+	return s
+}
+
+var _ RateLimitStore = &MemoryStore{}
+
+// Allow implements RateLimitStore, it never returns an error.
+func (s *MemoryStore) Allow(ctx context.Context, key string, rule RateLimitRule) (RateLimitResult, error) {
+	var h = fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	var shard = &s.shards[h.Sum32()%memoryStoreShards]
+	var now = s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.sweep(now)
+
+	var e = shard.entries[key]
+	if e == nil {
+		e = &rateEntry{}
+		shard.entries[key] = e
+	}
+	if rule.Algorithm == SlidingWindow {
+		return e.slidingWindow(now, rule), nil
+	}
+	return e.tokenBucket(now, rule), nil
+}
+
+// sweep drops expired entries, at most once per memoryStoreSweep.
+func (s *memoryShard) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memoryStoreSweep {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+func (e *rateEntry) tokenBucket(now time.Time, rule RateLimitRule) RateLimitResult {
+	var capacity = float64(rule.Burst)
+	if rule.Burst <= 0 {
+		capacity = float64(rule.Limit)
+	}
+	var rate = float64(rule.Limit) / rule.Window.Seconds() // tokens per second
+
+	if e.last.IsZero() {
+		e.tokens = capacity
+	} else {
+		e.tokens = math.Min(capacity, e.tokens+now.Sub(e.last).Seconds()*rate)
+	}
+	e.last = now
+
+	var res = RateLimitResult{Limit: int(capacity)}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - e.tokens) / rate)
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = seconds((capacity - e.tokens) / rate)
+	e.expires = now.Add(res.Reset)
+	return res
+}
+
+func (e *rateEntry) slidingWindow(now time.Time, rule RateLimitRule) RateLimitResult {
+	var start = now.Truncate(rule.Window)
+	switch {
+	case start.Equal(e.start):
+	case start.Sub(e.start) == rule.Window:
+		e.prev, e.curr = e.curr, 0
+	default:
+		e.prev, e.curr = 0, 0
+	}
+	e.start = start
+
+	var elapsed = now.Sub(start)
+	var weight = 1 - float64(elapsed)/float64(rule.Window)
+	var count = float64(e.prev)*weight + float64(e.curr)
+
+	var res = RateLimitResult{Limit: rule.Limit, Reset: rule.Window - elapsed}
+	if count+1 <= float64(rule.Limit) {
+		e.curr++
+		count++
+		res.Allowed = true
+	} else {
+		// wait until the previous window's share has decayed enough, or
+		// until the current window ends
+		res.RetryAfter = rule.Window - elapsed
+		if free := float64(rule.Limit - e.curr - 1); free >= 0 && e.prev > 0 {
+			var at = time.Duration(float64(rule.Window) * (1 - free/float64(e.prev)))
+			if at > elapsed && at-elapsed < res.RetryAfter {
+				res.RetryAfter = at - elapsed
+			}
+		}
+	}
+	res.Remaining = max(0, rule.Limit-int(math.Ceil(count)))
+	if e.curr > 0 {
+		res.Reset += rule.Window
+	}
+	e.expires = start.Add(2 * rule.Window)
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
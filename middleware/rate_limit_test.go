@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	var now = time.Unix(1000, 0)
+	var s = NewMemoryStore()
+	s.now = func() time.Time { return now }
+	var rule = RateLimitRule{Algorithm: TokenBucket, Limit: 2, Window: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if res, _ := s.Allow(context.Background(), "k", rule); !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	var res, _ = s.Allow(context.Background(), "k", rule)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("want limited with retry after 500ms, got %+v", res)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if res, _ = s.Allow(context.Background(), "k", rule); !res.Allowed {
+		t.Fatalf("want allowed after refill, got %+v", res)
+	}
+	if res, _ = s.Allow(context.Background(), "other", rule); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("keys must not share quota, got %+v", res)
+	}
+}
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	var now = time.Unix(960, 0) // aligned to a minute
+	var s = NewMemoryStore()
+	s.now = func() time.Time { return now }
+	var rule = RateLimitRule{Algorithm: SlidingWindow, Limit: 4, Window: time.Minute}
+
+	for i := 0; i < 4; i++ {
+		if res, _ := s.Allow(context.Background(), "k", rule); !res.Allowed {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	if res, _ := s.Allow(context.Background(), "k", rule); res.Allowed {
+		t.Fatalf("want limited, got %+v", res)
+	}
+
+	// a quarter into the next window, 3 of the previous 4 requests still count
+	now = now.Add(75 * time.Second)
+	if res, _ := s.Allow(context.Background(), "k", rule); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("want allowed with nothing remaining, got %+v", res)
+	}
+	var res, _ = s.Allow(context.Background(), "k", rule)
+	if res.Allowed || res.RetryAfter != 15*time.Second {
+		t.Fatalf("want limited with retry after 15s, got %+v", res)
+	}
+
+	// expired keys are swept by the next call hitting their shard
+	now = now.Add(time.Hour)
+	for i := range s.shards {
+		s.shards[i].sweep(now)
+		if _, ok := s.shards[i].entries["k"]; ok {
+			t.Fatalf("expired key not evicted")
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RateLimit(RateLimitOptions{Limit: 1, Window: time.Hour, KeyFunc: KeyByRouteAndIP}))
+	var ok = func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.NopResponse(http.StatusOK)
+	}
+	r.HandleFunc(http.MethodGet, "/a/:id", ok)
+	r.HandleFunc(http.MethodGet, "/b", ok)
+
+	var get = func(path, ip string) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var w = get("/a/1", "10.0.0.1")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	w = get("/a/2", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "3600" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	if w = get("/b", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("want separate quota per route, got %d", w.Code)
+	}
+	if w = get("/a/1", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("want separate quota per ip, got %d", w.Code)
+	}
+}
+
+func TestRateLimitIgnoresForwardingHeaders(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RateLimit(RateLimitOptions{Limit: 1, Window: time.Hour}))
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.NopResponse(http.StatusOK)
+	})
+
+	for i, xff := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "8.8.8.8:1234"
+		req.Header.Set("X-Forwarded-For", xff)
+		req.Header.Set("True-Client-IP", xff)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if i > 0 && w.Code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed X-Forwarded-For %s reset the bucket: %d", xff, w.Code)
+		}
+	}
+}
@@ -5,9 +5,10 @@ package middleware
 
 import (
 	"context"
-
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/ninthsoft/seed"
@@ -16,10 +17,19 @@ import (
 var trueClientIP = http.CanonicalHeaderKey("True-Client-IP")
 var xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
 var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
+var forwarded = http.CanonicalHeaderKey("Forwarded")
+
+// forwardingHeaders are the headers clients may use to claim another address.
+var forwardingHeaders = []string{forwarded, xForwardedFor, xRealIP, trueClientIP}
+
+// PeerAddrCtxKey is the context.Context key to store the original
+// RemoteAddr, i.e. the address of the immediate peer, before RealIP or
+// RealIPWithOptions replaced it.
+const PeerAddrCtxKey ContextKey = "__SeedPeerAddr__"
 
 // RealIP is a middleware that sets a http.Request's RemoteAddr to the results
 // of parsing either the True-Client-IP, X-Real-IP or the X-Forwarded-For headers
-// (in that order).
+// (in that order). RemoteAddr keeps the host:port form, with port 0.
 //
 // This middleware should be inserted fairly early in the middleware stack to
 // ensure that subsequent layers (e.g., request loggers) which examine the
@@ -32,12 +42,13 @@ var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
 // values from the client, or if you use this middleware without a reverse
 // proxy, malicious clients will be able to make you very sad (or, depending on
 // how you're using RemoteAddr, vulnerable to an attack of some sort).
+// Prefer RealIPWithOptions, which only honours the headers set by trusted
+// proxies.
 func RealIP(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
-	func(w http.ResponseWriter, r *http.Request) {
-		if rip := realIP(r); rip != "" {
-			r.RemoteAddr = rip
-		}
-	}(w, req)
+	if rip := realIP(req); rip != "" {
+		ctx = withPeerAddr(ctx, req.RemoteAddr)
+		req.RemoteAddr = net.JoinHostPort(rip, "0")
+	}
 	return next.Next(ctx, w, req)
 }
 
@@ -53,10 +64,172 @@ func realIP(r *http.Request) string {
 		if i == -1 {
 			i = len(xff)
 		}
-		ip = xff[:i]
+		ip = strings.TrimSpace(xff[:i])
 	}
 	if ip == "" || net.ParseIP(ip) == nil {
 		return ""
 	}
 	return ip
 }
+
+// RealIPOptions configures the RealIPWithOptions middleware.
+type RealIPOptions struct {
+	// TrustedProxies is a list of CIDRs ("10.0.0.0/8", "fd00::/8") or single
+	// IPs of the proxies in front of the server. Forwarding headers are only
+	// honoured when the immediate peer is one of them, and X-Forwarded-For and
+	// Forwarded are walked from the right skipping trusted hops. An invalid
+	// entry panics.
+	TrustedProxies []string
+}
+
+// RealIPWithOptions is a spoofing resistant RealIP.
+//
+// When the immediate peer is a trusted proxy, the client address is taken
+// from the RFC 7239 Forwarded header, or X-Forwarded-For when Forwarded is
+// absent, as the right-most address that is not a trusted proxy itself. Only
+// when neither header is present, X-Real-IP and then True-Client-IP are used.
+// Requests from other peers keep their RemoteAddr untouched and have these
+// forwarding headers removed.
+//
+// RemoteAddr is set in host:port form, with the port from Forwarded when given
+// and 0 otherwise. The original peer address stays available through
+// GetPeerAddr.
+func RealIPWithOptions(opts RealIPOptions) seed.MiddlewareFunc {
+	var trusted = make([]netip.Prefix, 0, len(opts.TrustedProxies))
+	for _, p := range opts.TrustedProxies {
+		var prefix, err = netip.ParsePrefix(p)
+		if err != nil {
+			var addr, err2 = netip.ParseAddr(p)
+			if err2 != nil {
+				panic("middleware: invalid trusted proxy " + p)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	var isTrusted = func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+		var peer, _ = parseAddrPort(req.RemoteAddr)
+		if !peer.IsValid() || !isTrusted(peer) {
+			// the headers came from the client itself, drop them so that later
+			// layers reading them (RealIP, loggers) cannot be spoofed either
+			for _, h := range forwardingHeaders {
+				req.Header.Del(h)
+			}
+			return next.Next(ctx, w, req)
+		}
+
+		var hops []string
+		if fwd := req.Header.Values(forwarded); len(fwd) > 0 {
+			hops = parseForwarded(fwd)
+		} else if xff := req.Header.Values(xForwardedFor); len(xff) > 0 {
+			for _, v := range xff {
+				for _, h := range strings.Split(v, ",") {
+					hops = append(hops, strings.TrimSpace(h))
+				}
+			}
+		} else if ip := req.Header.Get(xRealIP); ip != "" {
+			hops = []string{ip}
+		} else if ip := req.Header.Get(trueClientIP); ip != "" {
+			hops = []string{ip}
+		}
+
+		var client, port string
+		for i := len(hops) - 1; i >= 0; i-- {
+			var addr, p = parseAddrPort(hops[i])
+			if !addr.IsValid() {
+				break
+			}
+			client, port = addr.String(), p
+			if !isTrusted(addr) {
+				break
+			}
+		}
+		if client != "" {
+			if port == "" {
+				port = "0"
+			}
+			ctx = withPeerAddr(ctx, req.RemoteAddr)
+			req.RemoteAddr = net.JoinHostPort(client, port)
+		}
+		return next.Next(ctx, w, req)
+	}
+}
+
+// GetPeerAddr returns the address of the immediate peer as it was before
+// RealIP or RealIPWithOptions rewrote RemoteAddr, or "" when it was not
+// rewritten.
+func GetPeerAddr(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	var addr, _ = ctx.Value(PeerAddrCtxKey).(string)
+	return addr
+}
+
+// withPeerAddr records the peer address unless an earlier middleware already
+// did.
+func withPeerAddr(ctx context.Context, addr string) context.Context {
+	if GetPeerAddr(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, PeerAddrCtxKey, addr)
+}
+
+// parseAddrPort parses "ip", "ip:port", "[ipv6]" or "[ipv6]:port".
+func parseAddrPort(s string) (netip.Addr, string) {
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), strconv.Itoa(int(ap.Port()))
+	}
+	var addr, err = netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, ""
+	}
+	return addr.Unmap(), ""
+}
+
+// parseForwarded returns the for= node of every element of RFC 7239 Forwarded
+// headers. Obfuscated or "unknown" nodes are kept, so walking stops there.
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, elem := range splitQuoted(v, ',') {
+			for _, pair := range splitQuoted(elem, ';') {
+				var k, val, _ = strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(strings.TrimSpace(k), "for") {
+					hops = append(hops, strings.Trim(strings.TrimSpace(val), `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s at sep outside of double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted bool
+	var start int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			i++
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+func TestRealIPWithOptions(t *testing.T) {
+	var mw = RealIPWithOptions(RealIPOptions{TrustedProxies: []string{"10.0.0.0/8", "fd00::/8", "192.0.2.1"}})
+
+	for name, tc := range map[string]struct {
+		remote string
+		header map[string]string
+		want   string
+	}{
+		"untrusted peer": {
+			remote: "203.0.113.9:5000",
+			header: map[string]string{"X-Forwarded-For": "1.1.1.1"},
+			want:   "203.0.113.9:5000",
+		},
+		"rightmost untrusted": {
+			remote: "10.0.0.2:5000",
+			header: map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.1, 10.0.0.3"},
+			want:   "1.1.1.1:0",
+		},
+		"all trusted": {
+			remote: "10.0.0.2:5000",
+			header: map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.3"},
+			want:   "10.1.1.1:0",
+		},
+		"invalid hop": {
+			remote: "10.0.0.2:5000",
+			header: map[string]string{"X-Forwarded-For": "1.1.1.1, garbage, 10.0.0.3"},
+			want:   "10.0.0.3:0",
+		},
+		"forwarded ipv6": {
+			remote: "[fd00::1]:5000",
+			header: map[string]string{
+				"Forwarded":       `for=6.6.6.6, for="[2001:db8:cafe::17]:4711";proto=https`,
+				"X-Forwarded-For": "7.7.7.7",
+			},
+			want: "[2001:db8:cafe::17]:4711",
+		},
+		"forwarded unknown": {
+			remote: "192.0.2.1:5000",
+			header: map[string]string{"Forwarded": "for=unknown"},
+			want:   "192.0.2.1:5000",
+		},
+		"x-real-ip": {
+			remote: "10.0.0.2:5000",
+			header: map[string]string{"X-Real-IP": "2001:db8::1"},
+			want:   "[2001:db8::1]:0",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var r = seed.NewRouter()
+			r.Use(mw)
+			r.HandleStd(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.RemoteAddr != tc.want {
+					t.Errorf("want RemoteAddr %q, got %q", tc.want, req.RemoteAddr)
+				}
+				var peer = GetPeerAddr(req.Context())
+				if req.RemoteAddr != tc.remote && peer != tc.remote {
+					t.Errorf("want peer addr %q, got %q", tc.remote, peer)
+				}
+			}))
+
+			var req = httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+}
+
+func TestRealIPKeepsPort(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RealIP)
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.TextResponse(http.StatusOK, req.HTTPRequest().RemoteAddr+" "+GetPeerAddr(ctx))
+	})
+
+	var req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 10.0.0.3")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got, want := w.Body.String(), "1.1.1.1:0 10.0.0.2:5000"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestRealIPWithOptionsAndRateLimit(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RealIPWithOptions(RealIPOptions{TrustedProxies: []string{"10.0.0.0/8"}}))
+	r.Use(RateLimit(RateLimitOptions{Limit: 1, Window: time.Hour}))
+	r.HandleStd(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, h := range forwardingHeaders {
+			if req.Header.Get(h) != "" && req.RemoteAddr == "8.8.8.8:5000" {
+				t.Errorf("header %s from untrusted peer was kept", h)
+			}
+		}
+	}))
+
+	var get = func(remote, header, value string) int {
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		req.Header.Set(header, value)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// an untrusted peer cannot pick a new key per request
+	if code := get("8.8.8.8:5000", "True-Client-IP", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("want first request allowed, got %d", code)
+	}
+	for _, ip := range []string{"2.2.2.2", "3.3.3.3"} {
+		if code := get("8.8.8.8:5000", "True-Client-IP", ip); code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed True-Client-IP %s bypassed the limit: %d", ip, code)
+		}
+		if code := get("8.8.8.8:5000", "X-Forwarded-For", ip); code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed X-Forwarded-For %s bypassed the limit: %d", ip, code)
+		}
+	}
+
+	// clients behind a trusted proxy get their own quota
+	if code := get("10.0.0.2:5000", "X-Forwarded-For", "4.4.4.4"); code != http.StatusOK {
+		t.Fatalf("want client behind proxy allowed, got %d", code)
+	}
+	if code := get("10.0.0.2:5000", "X-Forwarded-For", "5.5.5.5"); code != http.StatusOK {
+		t.Fatalf("want second client behind proxy allowed, got %d", code)
+	}
+	if code := get("10.0.0.2:5000", "X-Forwarded-For", "4.4.4.4"); code != http.StatusTooManyRequests {
+		t.Fatalf("want client behind proxy limited, got %d", code)
+	}
+}
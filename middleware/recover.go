@@ -16,7 +16,7 @@ import (
 
 // Recoverer is a middleware that recovers from panics, logs the panic (and a
 // backtrace), and returns a HTTP 500 (Internal Server Error) status if
-// possible. Recoverer prints a request ID if one is provided.
+// possible. Recoverer prints a request ID if one is provided, see RequestID.
 func Recoverer(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
 	defer func() {
 		if rvr := recover(); rvr != nil {
@@ -29,7 +29,7 @@ func Recoverer(ctx context.Context, w http.ResponseWriter, req *http.Request, ne
 			if logEntry != nil {
 				logEntry.Panic(rvr, debug.Stack())
 			} else {
-				PrintPrettyStack(rvr)
+				printPrettyStack(rvr, GetReqID(req.Context()))
 			}
 			w.WriteHeader(http.StatusInternalServerError)
 		}
@@ -41,8 +41,12 @@ func Recoverer(ctx context.Context, w http.ResponseWriter, req *http.Request, ne
 var RecovererErrorWriter io.Writer = os.Stderr
 
 func PrintPrettyStack(rvr interface{}) {
+	printPrettyStack(rvr, "")
+}
+
+func printPrettyStack(rvr interface{}, reqID string) {
 	debugStack := debug.Stack()
-	s := prettyStack{}
+	s := prettyStack{reqID: reqID}
 	out, err := s.parse(debugStack, rvr)
 	if err == nil {
 		_, _ = RecovererErrorWriter.Write(out)
@@ -53,6 +57,7 @@ func PrintPrettyStack(rvr interface{}) {
 }
 
 type prettyStack struct {
+	reqID string
 }
 
 func (s prettyStack) parse(debugStack []byte, rvr interface{}) ([]byte, error) {
@@ -63,6 +68,11 @@ func (s prettyStack) parse(debugStack []byte, rvr interface{}) ([]byte, error) {
 	cW(buf, false, bRed, "\n")
 	cW(buf, useColor, bCyan, " panic: ")
 	cW(buf, useColor, bBlue, "%v", rvr)
+	if s.reqID != "" {
+		cW(buf, false, bWhite, "\n")
+		cW(buf, useColor, bCyan, " request: ")
+		cW(buf, useColor, nYellow, "%s", s.reqID)
+	}
 	cW(buf, false, bWhite, "\n \n")
 
 	// process debug stack info
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ninthsoft/seed"
+)
+
+// RequestIDHeader is the name of the HTTP Header which contains the request id.
+// Exported so that it can be changed by developers.
+var RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen bounds the length of a request id accepted from a client.
+const maxRequestIDLen = 128
+
+// RequestID is a middleware that injects a request ID into the context of each
+// request. An incoming X-Request-Id header is reused when it looks sane,
+// otherwise a new random (UUID v4) id is generated. The id is echoed back in
+// the response header of the same name.
+//
+// RequestID should go before Logger and Recoverer so both can print the id.
+func RequestID(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
+	var id = req.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return next.Next(seed.WithRequestID(ctx, id), w, req)
+}
+
+// GetReqID returns a request ID from the given context if one is present.
+// Returns the empty string if a request ID cannot be found.
+func GetReqID(ctx context.Context) string {
+	return seed.RequestIDFromContext(ctx)
+}
+
+// validRequestID reports whether a client supplied id is safe to log and echo:
+// non-empty, bounded and made of printable ASCII only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+func TestRequestID(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RequestID)
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.TextResponse(http.StatusOK, GetReqID(ctx)+" "+seed.RequestIDFromContext(req.HTTPRequest().Context()))
+	})
+
+	var get = func(id string) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			req.Header.Set(RequestIDHeader, id)
+		}
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for name, tc := range map[string]struct {
+		incoming string
+		keep     bool
+	}{
+		"generated":     {incoming: "", keep: false},
+		"accepted":      {incoming: "trace-abc_123.XYZ", keep: true},
+		"too long":      {incoming: strings.Repeat("a", maxRequestIDLen+1), keep: false},
+		"max length":    {incoming: strings.Repeat("a", maxRequestIDLen), keep: true},
+		"space":         {incoming: "a b", keep: false},
+		"control":       {incoming: "a\x01b", keep: false},
+		"non ascii":     {incoming: "请求", keep: false},
+		"log injection": {incoming: "a\tlevel=error", keep: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var w = get(tc.incoming)
+			var echoed = w.Header().Get(RequestIDHeader)
+			if tc.keep && echoed != tc.incoming {
+				t.Fatalf("want id %q kept, got %q", tc.incoming, echoed)
+			}
+			if !tc.keep {
+				if _, err := uuid.Parse(echoed); err != nil || echoed == tc.incoming {
+					t.Fatalf("want a generated uuid, got %q", echoed)
+				}
+			}
+			if w.Body.String() != echoed+" "+echoed {
+				t.Fatalf("handler saw %q, header has %q", w.Body.String(), echoed)
+			}
+		})
+	}
+
+	if a, b := get("").Header().Get(RequestIDHeader), get("").Header().Get(RequestIDHeader); a == b {
+		t.Fatalf("want distinct generated ids, got %q twice", a)
+	}
+}
+
+func TestRequestIDInErrorEnvelope(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(RequestID)
+	r.HandleFunc(http.MethodGet, "/fail", func(ctx context.Context, req seed.Request) seed.Response {
+		return render.JSON(ctx, nil, render.NewError("boom", 5000))
+	})
+	r.HandleFunc(http.MethodGet, "/ok", func(ctx context.Context, req seed.Request) seed.Response {
+		return render.JSON(ctx, "fine", nil)
+	})
+
+	var req = httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["request_id"] != "req-1" || resp["code"] != float64(5000) {
+		t.Fatalf("unexpected error envelope %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "request_id") {
+		t.Fatalf("success envelope must not carry request_id: %s", w.Body.String())
+	}
+}
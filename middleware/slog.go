@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+// SlogFormatter returns a LogFormatter that emits one structured record per
+// request through logger, suitable for JSON log pipelines. Use it with
+// RequestLogger:
+//
+//	r.Use(middleware.RequestID)
+//	r.Use(middleware.RequestLogger(middleware.SlogFormatter(slog.Default())))
+//	r.Use(middleware.Recoverer)
+//
+// Records are logged at Error level for 5xx responses, Warn for 4xx and Info
+// otherwise.
+func SlogFormatter(logger *slog.Logger) LogFormatter {
+	return &slogFormatter{logger: logger}
+}
+
+type slogFormatter struct {
+	logger *slog.Logger
+}
+
+// NewLogEntry creates a new LogEntry for the request.
+func (f *slogFormatter) NewLogEntry(r *http.Request) LogEntry {
+	return &slogLogEntry{logger: f.logger, request: r}
+}
+
+type slogLogEntry struct {
+	logger  *slog.Logger
+	request *http.Request
+}
+
+func (l *slogLogEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var level = slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+
+	var attrs = append(l.requestAttrs(),
+		slog.Int("status", status),
+		slog.Int("bytes", bytes),
+		slog.Duration("latency", elapsed),
+	)
+	if fields, ok := extra.(LogFields); ok {
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			attrs = append(attrs, slog.Any(k, fields[k]))
+		}
+	}
+	l.logger.LogAttrs(l.context(), level, "request completed", attrs...)
+}
+
+func (l *slogLogEntry) Panic(v interface{}, stack []byte) {
+	var attrs = append(l.requestAttrs(),
+		slog.Any("panic", v),
+		slog.String("stack", string(stack)),
+	)
+	l.logger.LogAttrs(l.context(), slog.LevelError, "request panic", attrs...)
+}
+
+func (l *slogLogEntry) context() context.Context {
+	return l.request.Context()
+}
+
+func (l *slogLogEntry) requestAttrs() []slog.Attr {
+	var r = l.request
+	var attrs = []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("route", seed.RoutePatternFromContext(r.Context())),
+		slog.String("remote_ip", remoteIP(r.RemoteAddr)),
+		slog.String("user_agent", r.UserAgent()),
+	}
+	if reqID := GetReqID(r.Context()); reqID != "" {
+		attrs = append(attrs, slog.String("request_id", reqID))
+	}
+	return attrs
+}
+
+// remoteIP strips the port from a RemoteAddr style address.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ninthsoft/seed"
+)
+
+// slogRecords decodes the JSON records written to buf.
+func slogRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	buf.Reset()
+	return records
+}
+
+func TestSlogFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	var logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var r = seed.NewRouter()
+	r.Use(RequestID, RequestLogger(SlogFormatter(logger)), Recoverer)
+	r.HandleFunc(http.MethodGet, "/status/:code", func(ctx context.Context, req seed.Request) seed.Response {
+		var code, _ = req.ParamInt("code")
+		return seed.TextResponse(code, "hello")
+	})
+	r.HandleFunc(http.MethodGet, "/panic", func(ctx context.Context, req seed.Request) seed.Response {
+		panic("boom")
+	})
+
+	var get = func(target string) {
+		var req = httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		req.RemoteAddr = "192.0.2.7:5000"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for _, tc := range []struct {
+		code  string
+		level string
+	}{
+		{"200", "INFO"},
+		{"302", "INFO"},
+		{"404", "WARN"},
+		{"503", "ERROR"},
+	} {
+		var status, _ = strconv.Atoi(tc.code)
+		get("/status/" + tc.code)
+		var records = slogRecords(t, &buf)
+		if len(records) != 1 {
+			t.Fatalf("%s: want 1 record, got %d", tc.code, len(records))
+		}
+		var rec = records[0]
+		if rec["level"] != tc.level {
+			t.Errorf("%s: want level %s, got %v", tc.code, tc.level, rec["level"])
+		}
+		var want = map[string]interface{}{
+			"msg":        "request completed",
+			"method":     http.MethodGet,
+			"path":       "/status/" + tc.code,
+			"route":      "/status/:code",
+			"remote_ip":  "192.0.2.7",
+			"request_id": "req-1",
+			"status":     float64(status),
+			"bytes":      float64(len("hello")),
+		}
+		for k, v := range want {
+			if rec[k] != v {
+				t.Errorf("%s: want %s=%v, got %v", tc.code, k, v, rec[k])
+			}
+		}
+		if latency, ok := rec["latency"].(float64); !ok || latency < 0 {
+			t.Errorf("%s: want non-negative latency, got %v", tc.code, rec["latency"])
+		}
+	}
+
+	get("/panic")
+	var panicked map[string]interface{}
+	for _, rec := range slogRecords(t, &buf) {
+		if rec["msg"] == "request panic" {
+			panicked = rec
+		}
+	}
+	if panicked == nil {
+		t.Fatalf("want a panic record")
+	}
+	if panicked["level"] != "ERROR" || panicked["panic"] != "boom" || panicked["route"] != "/panic" || panicked["request_id"] != "req-1" {
+		t.Errorf("unexpected panic record %v", panicked)
+	}
+	if stack, _ := panicked["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("want a stack trace, got %q", stack)
+	}
+}
@@ -1,30 +1,148 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
 )
 
-// Timeout is a middleware that cancels ctx after a given timeout and return
-// a 504 Gateway Timeout error to the client.
-//
-// It's required that you select the ctx.Done() channel to check for the signal
-// if the context has reached its deadline and return, otherwise the timeout
-// signal will be just ignored.
+// ErrTimeout is the error rendered by the default timeout response.
+var ErrTimeout = render.NewError(http.StatusText(http.StatusGatewayTimeout), 5040).WithStatus(http.StatusGatewayTimeout)
+
+// TimeoutOptions configures the TimeoutWithOptions middleware.
+type TimeoutOptions struct {
+	// Timeout is the maximum duration of the downstream handlers.
+	Timeout time.Duration
+
+	// Response builds the response sent to the client once the deadline
+	// passes. Default value renders ErrTimeout through render.JSON.
+	Response func(ctx context.Context, req *http.Request) seed.Response
+
+	// Skip reports whether a request opts out of output buffering, e.g. for
+	// streaming routes. Skipped requests keep the original writer (and so
+	// http.Flusher/http.Hijacker) and only get their ctx cancelled at the
+	// deadline, no timeout response is sent for them.
+	Skip func(req *http.Request) bool
+}
+
+// Timeout is a middleware that cancels ctx after a given timeout and returns
+// a 504 Gateway Timeout error to the client. See TimeoutWithOptions.
 func Timeout(timeout time.Duration) seed.MiddlewareFunc {
+	return TimeoutWithOptions(TimeoutOptions{Timeout: timeout})
+}
+
+// TimeoutWithOptions is a middleware that runs the downstream handlers with a
+// deadline, like http.TimeoutHandler.
+//
+// The handlers write into a buffer which is only copied to the client if they
+// finish in time. Otherwise the timeout response is sent as soon as the
+// deadline passes and any later write returns http.ErrHandlerTimeout. Handlers
+// should still select on ctx.Done() to stop doing useless work.
+//
+// Since the response is buffered, the writer seen by the handlers implements
+// neither http.Flusher nor http.Hijacker; use TimeoutOptions.Skip for routes
+// that need them.
+func TimeoutWithOptions(opts TimeoutOptions) seed.MiddlewareFunc {
+	if opts.Response == nil {
+		opts.Response = func(ctx context.Context, req *http.Request) seed.Response {
+			return render.JSON(ctx, nil, ErrTimeout)
+		}
+	}
+
 	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next seed.MiddleWareQueue) bool {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer func() {
-			cancel()
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+
+		if opts.Skip != nil && opts.Skip(req) {
+			return next.Next(ctx, w, req)
+		}
+
+		var tw = &timeoutWriter{h: make(http.Header)}
+		var done = make(chan bool, 1)
+		var panicChan = make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			done <- next.Next(ctx, tw, req)
+		}()
+
+		select {
+		case p := <-panicChan:
+			// re-panic on the serving goroutine so Recoverer can handle it
+			panic(p)
+		case ok := <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			var dst = w.Header()
+			for k, vv := range tw.h {
+				dst[k] = vv
+			}
+			if !tw.wroteHeader {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			_, _ = w.Write(tw.buf.Bytes())
+			return ok
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
 			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				w.WriteHeader(http.StatusGatewayTimeout)
+				_ = opts.Response(ctx, req).WriteTo(w)
 			}
-		}()
-		return next.Next(ctx, w, req.WithContext(ctx))
+			return false
+		}
+	}
+}
+
+// timeoutWriter buffers the response of the handlers until they finish, and
+// discards anything written after the deadline.
+type timeoutWriter struct {
+	h http.Header
+
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
 	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
 }
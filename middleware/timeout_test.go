@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninthsoft/seed"
+)
+
+func TestTimeoutSendsResponseAtDeadline(t *testing.T) {
+	var lateWrite = make(chan error, 1)
+	var r = seed.NewRouter()
+	r.Use(Timeout(20 * time.Millisecond))
+	r.HandleStd(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("too late"))
+		lateWrite <- err
+	}))
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("want status %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"code":5040`) {
+		t.Fatalf("want rendered timeout error, got %q", body)
+	}
+	if err := <-lateWrite; err != http.ErrHandlerTimeout {
+		t.Fatalf("want late write to fail with %v, got %v", http.ErrHandlerTimeout, err)
+	}
+	if strings.Contains(w.Body.String(), "too late") {
+		t.Fatal("late write leaked into the response")
+	}
+}
+
+func TestTimeoutCopiesBufferedResponse(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(Timeout(time.Second))
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req seed.Request) seed.Response {
+		return seed.TextResponse(http.StatusCreated, "ok", seed.WithHeader("X-Test", "1"))
+	})
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != "ok" || w.Header().Get("X-Test") != "1" {
+		t.Fatalf("unexpected response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestTimeoutSkipKeepsOriginalWriter(t *testing.T) {
+	var r = seed.NewRouter()
+	r.Use(TimeoutWithOptions(TimeoutOptions{
+		Timeout: time.Second,
+		Skip:    func(req *http.Request) bool { return true },
+	}))
+	r.HandleStd(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("want skipped route to keep http.Flusher")
+		}
+		if _, ok := req.Context().Deadline(); !ok {
+			t.Error("want skipped route to still get a deadline")
+		}
+	}))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
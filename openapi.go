@@ -0,0 +1,578 @@
+package seed
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIInfo OpenAPI 文档的 info 部分
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIOptions 生成 OpenAPI 文档的配置
+type OpenAPIOptions struct {
+	Info OpenAPIInfo
+
+	// Servers 服务地址，如 https://api.example.com
+	Servers []string
+
+	// WrapResponse 包装响应数据的 schema，data 为 Route.Response 对应的 schema，没有设置时为 nil
+	//
+	// 	如 render.JSON 会把数据放在 {code, msg, data} 中，详见 render.OpenAPIEnvelope
+	WrapResponse func(data *OpenAPISchema) *OpenAPISchema
+}
+
+// OpenAPIDocument OpenAPI 3.1 文档
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// OpenAPIServer 服务地址
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIComponents 可复用的定义，目前只有 schemas
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPIOperation 一个方法的接口定义
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter path、query、header 或 cookie 参数
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+
+	// order 字段在结构体中的顺序
+	order int
+}
+
+// OpenAPIRequestBody 请求体
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse 响应
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType 某种 Content-Type 的内容
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPISchema JSON Schema 的子集
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+}
+
+// NewOpenAPI 根据 r 中注册的路由生成 OpenAPI 3.1 文档
+//
+// 	路径参数取自路由模板，请求与响应的结构取自 Route.Request/Route.Response 或 TypedHandler
+// 	请求结构的字段按 bind 的规则处理: path/query/header/cookie tag 对应参数，其余字段(json/form tag)组成 json 请求体
+// 	validate tag 中的 required、min、max、minLen、maxLen、len、between、in、email、url、uuid、regex 等规则会转换为对应的约束
+// 	具名结构体放在 components/schemas 中
+func NewOpenAPI(r Router, opts OpenAPIOptions) *OpenAPIDocument {
+	var doc = &OpenAPIDocument{OpenAPI: "3.1.0", Info: opts.Info, Paths: map[string]map[string]*OpenAPIOperation{}}
+	if doc.Info.Title == "" {
+		doc.Info.Title = "API"
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	for _, s := range opts.Servers {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: s})
+	}
+
+	var g = &schemaGenerator{schemas: map[string]*OpenAPISchema{}, names: map[reflect.Type]string{}}
+	for _, rt := range r.registeredRoutes() {
+		var p = openAPIPath(rt.path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*OpenAPIOperation{}
+		}
+		for _, m := range rt.methods {
+			var op = g.operation(rt, m)
+			if opts.WrapResponse != nil {
+				var res = op.Responses["200"]
+				var data *OpenAPISchema
+				if res.Content != nil {
+					data = res.Content["application/json"].Schema
+				}
+				res.Content = map[string]*OpenAPIMediaType{"application/json": {Schema: opts.WrapResponse(data)}}
+			}
+			doc.Paths[p][strings.ToLower(m)] = op
+		}
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: g.schemas}
+	}
+	return doc
+}
+
+// JSON 返回 json 格式的文档
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML 返回 yaml 格式的文档，字段顺序与 JSON 一致
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	var bs, err = json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	// json 是合法的 yaml，解析为节点后去掉 flow 风格即可保留字段顺序
+	var node yaml.Node
+	if err = yaml.Unmarshal(bs, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	var buf bytes.Buffer
+	var enc = yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+func blockStyle(n *yaml.Node) {
+	// 字符串也去掉 json 的引号，yaml 会在需要时(如 "true"、"1")自动加上
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// OpenAPIHandler 返回提供 r 的 OpenAPI 文档的 handler
+//
+// 	默认返回 json，请求路径以 .yaml/.yml 结尾或带有 ?format=yaml 时返回 yaml，如:
+// 	s.HandleStd("GET", "/openapi.json", seed.OpenAPIHandler(s, opts))
+// 	s.HandleStd("GET", "/openapi.yaml", seed.OpenAPIHandler(s, opts))
+// 	文档在每次请求时生成，所以总是与注册的路由一致
+func OpenAPIHandler(r Router, opts OpenAPIOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var doc = NewOpenAPI(r, opts)
+		var bs []byte
+		var err error
+		var contentType = "application/json; charset=utf-8"
+		if strings.HasSuffix(req.URL.Path, ".yaml") || strings.HasSuffix(req.URL.Path, ".yml") || req.URL.Query().Get("format") == "yaml" {
+			bs, err = doc.YAML()
+			contentType = "application/yaml; charset=utf-8"
+		} else {
+			bs, err = doc.JSON()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(HeaderContentType, contentType)
+		_, _ = w.Write(bs)
+	})
+}
+
+// openAPIPath 把路由模板转换为 OpenAPI 的路径，如 /user/:id/*file 转换为 /user/{id}/{file}
+func openAPIPath(pattern string) string {
+	var segs = strings.Split(pattern, "/")
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// pathParamNames 返回路由模板中的参数名
+func pathParamNames(pattern string) []string {
+	var names []string
+	for _, s := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			names = append(names, s[1:])
+		}
+	}
+	return names
+}
+
+// schemaGenerator 根据 Go 类型生成 schema，具名结构体放在 schemas 中
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+// paramTags 对应 OpenAPI 参数位置的 bind tag
+var paramTags = []string{"path", "query", "header", "cookie"}
+
+func (g *schemaGenerator) operation(rt *Route, method string) *OpenAPIOperation {
+	var op = &OpenAPIOperation{
+		Summary:     rt.summary,
+		Description: rt.description,
+		Tags:        rt.tags,
+		Deprecated:  rt.deprecated,
+		OperationID: rt.operationID,
+		Responses:   map[string]*OpenAPIResponse{"200": {Description: http.StatusText(http.StatusOK)}},
+	}
+	if op.OperationID != "" && len(rt.methods) > 1 {
+		op.OperationID += "_" + strings.ToLower(method)
+	}
+
+	var params = map[string]*OpenAPIParameter{}
+	var body *OpenAPISchema
+	if rt.request != nil {
+		var fields []*OpenAPIParameter
+		fields, body = g.request(derefType(rt.request))
+		for _, p := range fields {
+			params[p.In+":"+p.Name] = p
+		}
+	}
+	for _, name := range pathParamNames(rt.path) {
+		var p = params["path:"+name]
+		if p == nil {
+			p = &OpenAPIParameter{Name: name, In: "path", Schema: &OpenAPISchema{Type: "string"}}
+		}
+		p.Required = true
+		op.Parameters = append(op.Parameters, p)
+		delete(params, "path:"+name)
+	}
+	for _, in := range paramTags[1:] {
+		for _, p := range orderedParams(params, in) {
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+
+	if body != nil && method != http.MethodGet && method != http.MethodHead && method != http.MethodDelete {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]*OpenAPIMediaType{"application/json": {Schema: body}},
+		}
+	}
+	if rt.response != nil {
+		op.Responses["200"].Content = map[string]*OpenAPIMediaType{"application/json": {Schema: g.schema(rt.response)}}
+	}
+	return op
+}
+
+// orderedParams 返回位置为 in 的参数，按字段顺序
+func orderedParams(params map[string]*OpenAPIParameter, in string) []*OpenAPIParameter {
+	var ps []*OpenAPIParameter
+	for _, p := range params {
+		if p.In == in {
+			ps = append(ps, p)
+		}
+	}
+	// map 无序，按参数在结构体中的顺序排列
+	sort.Slice(ps, func(i, j int) bool { return ps[i].order < ps[j].order })
+	return ps
+}
+
+// request 拆分请求结构为参数与 json 请求体，没有请求体字段时 body 为 nil
+func (g *schemaGenerator) request(t reflect.Type) (params []*OpenAPIParameter, body *OpenAPISchema) {
+	if t.Kind() != reflect.Struct {
+		return nil, g.schema(t)
+	}
+	var order int
+	walkFields(t, func(f reflect.StructField) {
+		for _, in := range paramTags {
+			var name, ok = tagName(f, in)
+			if !ok {
+				continue
+			}
+			var s = g.schema(f.Type)
+			var required = applyRules(s, f)
+			order++
+			params = append(params, &OpenAPIParameter{Name: name, In: in, Required: required || in == "path", Schema: s, order: order})
+			return
+		}
+
+		var name, ok = jsonName(f)
+		if !ok {
+			return
+		}
+		if body == nil {
+			body = &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+		}
+		var s = g.schema(f.Type)
+		if applyRules(s, f) {
+			body.Required = append(body.Required, name)
+		}
+		body.Properties[name] = s
+	})
+	return params, body
+}
+
+// schema 返回类型 t 对应的 schema，具名结构体返回 $ref
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	t = derefType(t)
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(uuid.UUID{}):
+		return &OpenAPISchema{Type: "string", Format: "uuid"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return &OpenAPISchema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		var s = &OpenAPISchema{Type: "integer", Format: "int64"}
+		if t.Kind() == reflect.Uint || t.Kind() == reflect.Uint64 {
+			s.Minimum = float(0)
+		}
+		return s
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		var s = &OpenAPISchema{Type: "integer", Format: "int32"}
+		if t.Kind() >= reflect.Uint8 {
+			s.Minimum = float(0)
+		}
+		return s
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + g.register(t)}
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+// register 把具名结构体放入 schemas 并返回其名称，名称冲突时追加序号
+func (g *schemaGenerator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	var base = schemaNameRe.ReplaceAllString(t.Name(), "_")
+	var name = base
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	// 先占位，避免递归的结构体无限展开
+	g.names[t] = name
+	g.schemas[name] = &OpenAPISchema{}
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+var schemaNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// object 返回结构体的 object schema，字段名取自 json tag
+func (g *schemaGenerator) object(t reflect.Type) *OpenAPISchema {
+	var s = &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	walkFields(t, func(f reflect.StructField) {
+		var name, ok = jsonName(f)
+		if !ok {
+			return
+		}
+		var fs = g.schema(f.Type)
+		if applyRules(fs, f) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	})
+	return s
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// walkFields 遍历导出的字段，没有 tag 的匿名结构体字段会被展开
+func walkFields(t reflect.Type, fn func(f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if f.Anonymous && derefType(f.Type).Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			walkFields(derefType(f.Type), fn)
+			continue
+		}
+		if f.IsExported() {
+			fn(f)
+		}
+	}
+}
+
+// tagName 返回字段 tag 中的名称，没有该 tag 或为 "-" 时 ok 为 false
+func tagName(f reflect.StructField, tag string) (name string, ok bool) {
+	var v, has = f.Tag.Lookup(tag)
+	if !has {
+		return "", false
+	}
+	name, _, _ = strings.Cut(v, ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// jsonName 返回字段在 json 中的名称，优先 json tag，其次 form tag，都没有时使用字段名
+func jsonName(f reflect.StructField) (string, bool) {
+	if v, has := f.Tag.Lookup("json"); has {
+		var name, _, _ = strings.Cut(v, ",")
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+		return f.Name, true
+	}
+	if name, ok := tagName(f, "form"); ok {
+		return name, true
+	}
+	return f.Name, true
+}
+
+// applyRules 把 validate tag 中的规则转换为 s 的约束，返回是否必填
+func applyRules(s *OpenAPISchema, f reflect.StructField) (required bool) {
+	var kind = derefType(f.Type).Kind()
+	var isNumber = kind >= reflect.Int && kind <= reflect.Float64
+	var isArray = kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+
+	var setMin = func(v string) {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			switch {
+			case isNumber:
+				s.Minimum = float(n)
+			case isArray:
+				s.MinItems = intPtr(int(n))
+			default:
+				s.MinLength = intPtr(int(n))
+			}
+		}
+	}
+	var setMax = func(v string) {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			switch {
+			case isNumber:
+				s.Maximum = float(n)
+			case isArray:
+				s.MaxItems = intPtr(int(n))
+			default:
+				s.MaxLength = intPtr(int(n))
+			}
+		}
+	}
+
+	for _, rule := range strings.Split(f.Tag.Get("validate"), "|") {
+		var name, arg, _ = strings.Cut(strings.TrimSpace(rule), ":")
+		var args = strings.Split(arg, ",")
+		switch strings.ToLower(strings.ReplaceAll(name, "_", "")) {
+		case "required":
+			required = true
+		case "min", "minlen", "minlength", "minsize":
+			setMin(arg)
+		case "max", "maxlen", "maxlength", "maxsize":
+			setMax(arg)
+		case "len", "length", "size":
+			setMin(arg)
+			setMax(arg)
+		case "between", "range":
+			if len(args) == 2 {
+				setMin(args[0])
+				setMax(args[1])
+			}
+		case "in", "enum":
+			for _, a := range args {
+				if n, err := strconv.ParseFloat(a, 64); err == nil && isNumber {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, a)
+				}
+			}
+		case "email", "isemail":
+			s.Format = "email"
+		case "url", "isurl", "fullurl", "isfullurl":
+			s.Format = "uri"
+		case "uuid", "isuuid", "uuid4", "isuuid4":
+			s.Format = "uuid"
+		case "ipv4", "isipv4":
+			s.Format = "ipv4"
+		case "ipv6", "isipv6":
+			s.Format = "ipv6"
+		case "date", "isdate":
+			s.Format = "date"
+		case "regex", "regexp":
+			s.Pattern = arg
+		}
+	}
+	return required
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,127 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type openAPIUser struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Created time.Time      `json:"created"`
+	Friends []*openAPIUser `json:"friends,omitempty"`
+}
+
+type openAPICreateUser struct {
+	Tenant string   `path:"tenant"`
+	Trace  string   `header:"X-Trace"`
+	Dry    bool     `query:"dry"`
+	Name   string   `json:"name" validate:"required|minLen:2|maxLen:20"`
+	Age    int      `json:"age" validate:"min:1|max:150"`
+	Email  string   `json:"email" validate:"email"`
+	Role   string   `json:"role" validate:"in:admin,user"`
+	Tags   []string `json:"tags"`
+	Secret string   `json:"-"`
+}
+
+func openAPITestRouter() Router {
+	var r = NewRouter()
+	r.Group("/api/:tenant", func(r Router) {
+		r.RouteFunc(http.MethodPost, "/users", func(ctx context.Context, req Request) Response { return nil }).
+			Summary("create user").
+			Tags("user").
+			OperationID("createUser").
+			Request(openAPICreateUser{}).
+			Response(openAPIUser{})
+	})
+	r.Route("GET,DELETE", "/files/*path", http.NotFoundHandler()).OperationID("file").Deprecated()
+	return r
+}
+
+func TestOpenAPI(t *testing.T) {
+	var doc = NewOpenAPI(openAPITestRouter(), OpenAPIOptions{Info: OpenAPIInfo{Title: "test", Version: "1.0"}})
+	var bs, err = doc.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(bs, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["openapi"] != "3.1.0" {
+		t.Fatalf("unexpected version %v", m["openapi"])
+	}
+
+	var op = doc.Paths["/api/{tenant}/users"]["post"]
+	if op == nil || op.Summary != "create user" || op.OperationID != "createUser" {
+		t.Fatalf("unexpected operation %s", bs)
+	}
+	var names []string
+	for _, p := range op.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	if strings.Join(names, ",") != "path:tenant,query:dry,header:X-Trace" || !op.Parameters[0].Required {
+		t.Fatalf("unexpected parameters %v", names)
+	}
+
+	var body = op.RequestBody.Content["application/json"].Schema
+	if len(body.Required) != 1 || body.Required[0] != "name" || body.Properties["secret"] != nil || body.Properties["Secret"] != nil {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if name := body.Properties["name"]; *name.MinLength != 2 || *name.MaxLength != 20 {
+		t.Fatalf("unexpected name schema %+v", name)
+	}
+	if age := body.Properties["age"]; age.Type != "integer" || *age.Minimum != 1 || *age.Maximum != 150 {
+		t.Fatalf("unexpected age schema %+v", age)
+	}
+	if body.Properties["email"].Format != "email" || len(body.Properties["role"].Enum) != 2 || body.Properties["tags"].Items.Type != "string" {
+		t.Fatalf("unexpected body %+v", body)
+	}
+
+	var res = op.Responses["200"].Content["application/json"].Schema
+	if res.Ref != "#/components/schemas/openAPIUser" {
+		t.Fatalf("unexpected response schema %+v", res)
+	}
+	var user = doc.Components.Schemas["openAPIUser"]
+	if user.Properties["created"].Format != "date-time" || user.Properties["friends"].Items.Ref != res.Ref {
+		t.Fatalf("unexpected user schema %+v", user)
+	}
+
+	var get, del = doc.Paths["/files/{path}"]["get"], doc.Paths["/files/{path}"]["delete"]
+	if get == nil || del == nil || get.OperationID != "file_get" || !del.Deprecated || get.Parameters[0].Name != "path" {
+		t.Fatalf("unexpected file operations %s", bs)
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	var r = openAPITestRouter()
+	var wrap = func(data *OpenAPISchema) *OpenAPISchema {
+		return &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{"data": data}}
+	}
+	r.HandleStd(http.MethodGet, "/openapi.json", OpenAPIHandler(r, OpenAPIOptions{WrapResponse: wrap}))
+	r.HandleStd(http.MethodGet, "/openapi.yaml", OpenAPIHandler(r, OpenAPIOptions{}))
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var res = doc.Paths["/api/{tenant}/users"]["post"].Responses["200"].Content["application/json"].Schema
+	if res.Properties["data"].Ref != "#/components/schemas/openAPIUser" || doc.Paths["/openapi.json"] == nil {
+		t.Fatalf("unexpected document %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	var body = w.Body.String()
+	if !strings.HasPrefix(w.Header().Get(HeaderContentType), "application/yaml") ||
+		!strings.Contains(body, "openapi: 3.1.0\n") || !strings.Contains(body, "/api/{tenant}/users:") {
+		t.Fatalf("unexpected yaml %s", body)
+	}
+}
@@ -1,8 +1,34 @@
 package render
 
+import "net/http"
+
+// CodeError 携带业务错误码的错误
+type CodeError interface {
+	error
+	Code() int
+}
+
+// DetailError 携带详细信息的错误，如 bind.ValidationError
+//
+// 	DefaultJsonRender 会把 Details 序列化到 details 字段
+type DetailError interface {
+	CodeError
+	Details() interface{}
+}
+
+// StatusError 指定了 HTTP 状态码的错误，Status 返回 0 表示未指定
+type StatusError interface {
+	error
+	Status() int
+}
+
+// ErrBodyTooLarge 请求体超过限制，DefaultJsonRender 会把 *http.MaxBytesError 转换为该错误
+var ErrBodyTooLarge = NewError(http.StatusText(http.StatusRequestEntityTooLarge), 4130).WithStatus(http.StatusRequestEntityTooLarge)
+
 type Error struct {
-	code int
-	msg  string
+	code   int
+	msg    string
+	status int
 }
 
 func (e Error) Error() string {
@@ -13,6 +39,19 @@ func (e Error) Code() int {
 	return e.code
 }
 
+// Status 返回该错误对应的 HTTP 状态码，0 表示未指定(由 JsonRender 决定)
+func (e Error) Status() int {
+	return e.status
+}
+
+// WithStatus 返回一个指定了 HTTP 状态码的副本
+//
+// 	DefaultJsonRender 会以该状态码写出响应，如 render.NewError("timeout", 5040).WithStatus(504)
+func (e Error) WithStatus(status int) Error {
+	e.status = status
+	return e
+}
+
 func NewError(msg string, codes ...int) Error {
 	var code = 1
 	if len(codes) > 0 {
@@ -2,15 +2,18 @@ package render
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/ninthsoft/seed"
 )
 
 type jsonTmpl struct {
-	Code int         `json:"code"`
-	Msg  string      `json:"msg"`
-	Data interface{} `json:"data"`
+	Code      int         `json:"code"`
+	Msg       string      `json:"msg"`
+	Data      interface{} `json:"data"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 var DefaultJsonRender JsonRender = defaultJsonRender{}
@@ -28,13 +31,47 @@ func (r defaultJsonRender) Format(ctx context.Context, data interface{}, err err
 		Msg:  "success",
 		Data: data,
 	}
-	if e, ok := err.(Error); ok {
+	var status = http.StatusOK
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		err = ErrBodyTooLarge
+	}
+	if e, ok := err.(CodeError); ok {
 		resp.Code = e.Code()
 		resp.Msg = e.Error()
 	}
-	return http.StatusOK, resp
+	if e, ok := err.(DetailError); ok {
+		resp.Details = e.Details()
+	}
+	if e, ok := err.(StatusError); ok && e.Status() != 0 {
+		status = e.Status()
+	}
+	if err != nil {
+		resp.RequestID = seed.RequestIDFromContext(ctx)
+	}
+	return status, resp
 }
 
 var JSON = func(ctx context.Context, data interface{}, err error) (r seed.Response) {
 	return seed.JsonResponse(DefaultJsonRender.Format(ctx, data, err))
 }
+
+// OpenAPIEnvelope 把响应数据的 schema 包装为 JSON 使用的 {code, msg, data} 结构
+//
+// 	用于 seed.OpenAPIOptions.WrapResponse
+func OpenAPIEnvelope(data *seed.OpenAPISchema) *seed.OpenAPISchema {
+	if data == nil {
+		data = &seed.OpenAPISchema{}
+	}
+	return &seed.OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*seed.OpenAPISchema{
+			"code":       {Type: "integer"},
+			"msg":        {Type: "string"},
+			"data":       data,
+			"details":    {},
+			"request_id": {Type: "string"},
+		},
+		Required: []string{"code", "msg", "data"},
+	}
+}
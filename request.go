@@ -1,12 +1,20 @@
 package seed
 
 import (
+	"crypto/x509"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
 )
 
+// ErrParamNotFound 路径参数不存在
+var ErrParamNotFound = errors.New("path param not found")
+
 type Request interface {
 	// HTTPRequest 返回原始 *http.Request
 	HTTPRequest() *http.Request
@@ -23,6 +31,18 @@ type Request interface {
 	// PostFormDefault 获取POST方式传递的参数如果没有那么返回默认值/空值
 	PostFormDefault(name string, defaultValue ...string) (value string)
 
+	// Param 获取路由中的路径参数，如 /user/:id 中的 id
+	Param(name string) (value string, has bool)
+
+	// ParamDefault 获取路径参数如果没有那么返回默认值/空值
+	ParamDefault(name string, defaultValue ...string) (value string)
+
+	// ParamInt 获取路径参数并转换为 int
+	ParamInt(name string) (value int, err error)
+
+	// ParamUUID 获取路径参数并解析为 uuid.UUID
+	ParamUUID(name string) (value uuid.UUID, err error)
+
 	// Header 获取Header传递的参数
 	Header(name string) (value string, has bool)
 
@@ -35,15 +55,27 @@ type Request interface {
 	// RemoteAddr 获取客户端的请求地址
 	RemoteAddr() string
 
+	// ClientCertificate 获取双向 TLS 中验证通过的客户端证书，没有时返回 nil
+	//
+	// 	可以通过 Subject、DNSNames、URIs 等识别客户端，详见 WithTLSClientCA
+	ClientCertificate() *x509.Certificate
+
 	// JsonUnmarshal json序列化参数到目标数据
 	JsonUnmarshal(dst interface{}) error
+
+	// Body 返回完整的请求体，请求体会被缓存，可以重复调用，详见 ReadBody
+	Body() ([]byte, error)
+
+	// Decode 根据 Content-Type 把请求体解码到 dst
+	//
+	// 	支持 json(默认)、xml、msgpack、x-www-form-urlencoded 与 multipart/form-data(使用 form tag)
+	// 	其他类型返回 ErrUnsupportedMediaType
+	Decode(dst interface{}) error
 }
 
 type request struct {
 	urlQuery url.Values
-	bytes    []byte
 
-	read bool
 	*http.Request
 }
 
@@ -93,6 +125,48 @@ func (r *request) PostFormDefault(name string, defaultValue ...string) string {
 	return v
 }
 
+func (r *request) Param(name string) (value string, has bool) {
+	for _, p := range ParamsFromContext(r.Context()) {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (r *request) ParamDefault(name string, defaultValue ...string) (value string) {
+	var v string
+	if v, _ = r.Param(name); v != "" {
+		return v
+	}
+	if len(defaultValue) > 0 {
+		v = defaultValue[0]
+	}
+	return v
+}
+
+func (r *request) ParamInt(name string) (value int, err error) {
+	var v, has = r.Param(name)
+	if !has {
+		return 0, fmt.Errorf("%w: '%s'", ErrParamNotFound, name)
+	}
+	if value, err = strconv.Atoi(v); err != nil {
+		return 0, fmt.Errorf("invalid int path param '%s': %w", name, err)
+	}
+	return value, nil
+}
+
+func (r *request) ParamUUID(name string) (value uuid.UUID, err error) {
+	var v, has = r.Param(name)
+	if !has {
+		return uuid.Nil, fmt.Errorf("%w: '%s'", ErrParamNotFound, name)
+	}
+	if value, err = uuid.Parse(v); err != nil {
+		return uuid.Nil, fmt.Errorf("invalid uuid path param '%s': %w", name, err)
+	}
+	return value, nil
+}
+
 func (r *request) Header(name string) (value string, has bool) {
 	var vs = r.Request.Header.Values(name)
 	if len(vs) == 0 {
@@ -124,15 +198,27 @@ func (r *request) RemoteAddr() string {
 	return r.Request.RemoteAddr
 }
 
+func (r *request) ClientCertificate() *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
 func (r *request) JsonUnmarshal(dst interface{}) error {
-	var err error
-	if !r.read {
-		if r.bytes, err = io.ReadAll(r.Body); err == nil {
-			r.read = true
-		}
+	var bs, err = r.Body()
+	if err != nil {
+		return err
 	}
-	err = json.Unmarshal(r.bytes, dst)
-	return err
+	return json.Unmarshal(bs, dst)
+}
+
+func (r *request) Body() ([]byte, error) {
+	return ReadBody(r.Request)
+}
+
+func (r *request) Decode(dst interface{}) error {
+	return decodeBody(r.Request, dst)
 }
 
 // NewRequest 返回Request实例
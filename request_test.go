@@ -0,0 +1,147 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestBodyCanBeReadAgainAfterPeek(t *testing.T) {
+	var req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"alice"}`))
+
+	// a middleware peeks at the body
+	if bs, err := ReadBody(req); err != nil || string(bs) != `{"name":"alice"}` {
+		t.Fatalf("unexpected peek result %q %v", bs, err)
+	}
+
+	var dst struct {
+		Name string `json:"name"`
+	}
+	var r = NewRequest(req)
+	if err := r.JsonUnmarshal(&dst); err != nil || dst.Name != "alice" {
+		t.Fatalf("unexpected result %+v %v", dst, err)
+	}
+	if bs, err := r.Body(); err != nil || string(bs) != `{"name":"alice"}` {
+		t.Fatalf("unexpected body %q %v", bs, err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" form:"name" msgpack:"name"`
+	}
+	var packed, _ = msgpack.Marshal(user{Name: "alice"})
+
+	var cases = []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "", body: `{"name":"alice"}`},
+		{contentType: "application/json; charset=utf-8", body: `{"name":"alice"}`},
+		{contentType: "application/xml", body: `<user><name>alice</name></user>`},
+		{contentType: "application/x-www-form-urlencoded", body: "name=alice"},
+		{contentType: "multipart/form-data; boundary=b", body: "--b\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nalice\r\n--b--\r\n"},
+		{contentType: "application/msgpack", body: string(packed)},
+	}
+	for _, c := range cases {
+		t.Run(c.contentType, func(t *testing.T) {
+			var req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.body))
+			if c.contentType != "" {
+				req.Header.Set(HeaderContentType, c.contentType)
+			}
+			var dst user
+			if err := NewRequest(req).Decode(&dst); err != nil || dst.Name != "alice" {
+				t.Fatalf("unexpected result %+v %v", dst, err)
+			}
+		})
+	}
+
+	var req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("x"))
+	req.Header.Set(HeaderContentType, "image/png")
+	if err := NewRequest(req).Decode(&user{}); err != ErrUnsupportedMediaType {
+		t.Fatalf("want %v, got %v", ErrUnsupportedMediaType, err)
+	}
+}
+
+func TestParams(t *testing.T) {
+	var id = uuid.New()
+	var r = NewRouter()
+	r.HandleFunc(http.MethodGet, "/user/:id/:uid/*file", func(ctx context.Context, req Request) Response {
+		if v, has := req.Param("id"); v != "42" || !has {
+			t.Errorf("Param(id) = %q, %v", v, has)
+		}
+		if v, has := req.Param("missing"); v != "" || has {
+			t.Errorf("Param(missing) = %q, %v", v, has)
+		}
+		if v, _ := req.Param("file"); v != "/a/b.txt" {
+			t.Errorf("Param(file) = %q", v)
+		}
+
+		if v := req.ParamDefault("id", "0"); v != "42" {
+			t.Errorf("ParamDefault(id) = %q", v)
+		}
+		if v := req.ParamDefault("missing", "fallback"); v != "fallback" {
+			t.Errorf("ParamDefault(missing) = %q", v)
+		}
+		if v := req.ParamDefault("missing"); v != "" {
+			t.Errorf("ParamDefault(missing) without default = %q", v)
+		}
+
+		if v, err := req.ParamInt("id"); v != 42 || err != nil {
+			t.Errorf("ParamInt(id) = %d, %v", v, err)
+		}
+		if _, err := req.ParamInt("missing"); !errors.Is(err, ErrParamNotFound) {
+			t.Errorf("ParamInt(missing) err = %v", err)
+		}
+		if _, err := req.ParamInt("uid"); err == nil || errors.Is(err, ErrParamNotFound) {
+			t.Errorf("ParamInt(uid) err = %v", err)
+		}
+
+		if v, err := req.ParamUUID("uid"); v != id || err != nil {
+			t.Errorf("ParamUUID(uid) = %s, %v", v, err)
+		}
+		if _, err := req.ParamUUID("missing"); !errors.Is(err, ErrParamNotFound) {
+			t.Errorf("ParamUUID(missing) err = %v", err)
+		}
+		if _, err := req.ParamUUID("id"); err == nil || errors.Is(err, ErrParamNotFound) {
+			t.Errorf("ParamUUID(id) err = %v", err)
+		}
+		return NopResponse(http.StatusOK)
+	})
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/42/"+id.String()+"/a/b.txt", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+}
+
+func TestParamsFromContextInStdHandler(t *testing.T) {
+	var r = NewRouter()
+	var seen string
+	r.Use(func(ctx context.Context, w http.ResponseWriter, req *http.Request, next MiddleWareQueue) bool {
+		seen = ParamsFromContext(req.Context()).ByName("id")
+		return next.Next(ctx, w, req)
+	})
+	r.HandleStd(http.MethodGet, "/user/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ps = ParamsFromContext(req.Context())
+		_, _ = w.Write([]byte(ps.ByName("id")))
+	}))
+	r.HandleStd(http.MethodGet, "/static", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ps := ParamsFromContext(req.Context()); ps != nil {
+			t.Errorf("want nil params, got %v", ps)
+		}
+	}))
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/7", nil))
+	if w.Body.String() != "7" || seen != "7" {
+		t.Fatalf("handler saw %q, middleware saw %q", w.Body.String(), seen)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static", nil))
+}
@@ -2,7 +2,13 @@ package seed
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 )
 
@@ -12,15 +18,101 @@ const (
 
 	// HeaderContentLength HTTP Header 中 Content-Length 的 Key
 	HeaderContentLength = "Content-Length"
+
+	// HeaderContentDisposition HTTP Header 中 Content-Disposition 的 Key
+	HeaderContentDisposition = "Content-Disposition"
+
+	// HeaderLocation HTTP Header 中 Location 的 Key
+	HeaderLocation = "Location"
 )
 
 type Response interface {
 	WriteTo(w http.ResponseWriter) error
 }
 
-type jsonResponse struct {
+// requestResponse 需要原始请求才能完整写出的 Response，HandlerFunc 会优先调用 serve
+type requestResponse interface {
+	Response
+	serve(w http.ResponseWriter, req *http.Request) error
+}
+
+// ResponseOption 用于给 Response 追加额外的 Header、Cookie 等
+type ResponseOption func(r *response)
+
+// WithHeader 给响应追加 Header
+func WithHeader(key, value string) ResponseOption {
+	return func(r *response) {
+		if r.header == nil {
+			r.header = http.Header{}
+		}
+		r.header.Add(key, value)
+	}
+}
+
+// WithCookie 给响应设置 Cookie
+func WithCookie(cookie *http.Cookie) ResponseOption {
+	return func(r *response) {
+		r.cookies = append(r.cookies, cookie)
+	}
+}
+
+// WithAttachment 以附件形式下载，filename 为客户端保存的文件名
+func WithAttachment(filename string) ResponseOption {
+	return WithHeader(HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
+
+// response 所有内置 Response 共用的写出流程
+//
+// 	依次写出 额外的 Header/Cookie -> Content-Type/Content-Length -> 状态码 -> body
+type response struct {
 	statusCode int
-	data       interface{}
+	header     http.Header
+	cookies    []*http.Cookie
+}
+
+func newResponse(statusCode int, opts []ResponseOption) response {
+	var r = response{statusCode: statusCode}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// writeHeader 写出 Header 与状态码
+//
+// 	contentLength < 0 时表示长度未知，不设置 Content-Length
+func (r *response) writeHeader(w http.ResponseWriter, contentType string, contentLength int) {
+	r.addHeader(w)
+
+	var contentLen string
+	if contentLength >= 0 {
+		contentLen = strconv.Itoa(contentLength)
+	}
+	writeHeaderIfNot(w.Header(), contentType, contentLen)
+	w.WriteHeader(r.statusCode)
+}
+
+// addHeader 追加选项中的 Header 与 Cookie
+func (r *response) addHeader(w http.ResponseWriter) {
+	var h = w.Header()
+	for k, vs := range r.header {
+		h[k] = append(h[k], vs...)
+	}
+	for _, c := range r.cookies {
+		http.SetCookie(w, c)
+	}
+}
+
+// write 写出完整的响应
+func (r *response) write(w http.ResponseWriter, contentType string, bs []byte) error {
+	r.writeHeader(w, contentType, len(bs))
+	var _, err = w.Write(bs)
+	return err
+}
+
+type jsonResponse struct {
+	response
+	data interface{}
 }
 
 func (j *jsonResponse) WriteTo(w http.ResponseWriter) error {
@@ -29,57 +121,212 @@ func (j *jsonResponse) WriteTo(w http.ResponseWriter) error {
 		w.WriteHeader(http.StatusInternalServerError)
 		return err
 	}
-
-	writeHeaderIfNot(w.Header(), "application/json; charset=utf-8", strconv.Itoa(len(bs)))
-	_, err = w.Write(bs)
-	return err
+	return j.write(w, "application/json; charset=utf-8", bs)
 }
 
 var _ Response = &jsonResponse{}
 
 // JsonResponse 返回JsonResponse
-func JsonResponse(statusCode int, data interface{}) Response {
-	return &jsonResponse{statusCode: statusCode, data: data}
+func JsonResponse(statusCode int, data interface{}, opts ...ResponseOption) Response {
+	return &jsonResponse{response: newResponse(statusCode, opts), data: data}
+}
+
+type xmlResponse struct {
+	response
+	data interface{}
+}
+
+func (x *xmlResponse) WriteTo(w http.ResponseWriter) error {
+	var bs, err = xml.Marshal(x.data)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+	return x.write(w, "application/xml; charset=utf-8", append([]byte(xml.Header), bs...))
+}
+
+var _ Response = &xmlResponse{}
+
+// XMLResponse 返回 xml 格式的 Response
+func XMLResponse(statusCode int, data interface{}, opts ...ResponseOption) Response {
+	return &xmlResponse{response: newResponse(statusCode, opts), data: data}
 }
 
 type nopResponse struct {
-	statusCode int
+	response
 }
 
 func (n *nopResponse) WriteTo(w http.ResponseWriter) error {
-	w.WriteHeader(n.statusCode)
+	n.writeHeader(w, "", -1)
 	return nil
 }
 
 var _ Response = &nopResponse{}
 
-// NopResponse 返回一个nopResponse
-func NopResponse(statusCode int) Response {
-	return &nopResponse{statusCode: statusCode}
+// NopResponse 返回只有状态码(与 opts 设置的 Header、Cookie)没有 body 的 Response
+func NopResponse(statusCode int, opts ...ResponseOption) Response {
+	return &nopResponse{response: newResponse(statusCode, opts)}
 }
 
-type htmlResponse struct {
-	statusCode int
-	html       string
+type textResponse struct {
+	response
+	contentType string
+	text        string
 }
 
-func (h *htmlResponse) WriteTo(w http.ResponseWriter) error {
-	var bs = []byte(h.html)
-	writeHeaderIfNot(w.Header(), "text/plain; charset=utf-8", strconv.Itoa(len(bs)))
+func (t *textResponse) WriteTo(w http.ResponseWriter) error {
+	return t.write(w, t.contentType, []byte(t.text))
+}
 
-	var _, err = w.Write(bs)
-	return err
+var _ Response = &textResponse{}
+
+// HtmlResponse 返回 html 格式的 Response
+func HtmlResponse(statusCode int, html string, opts ...ResponseOption) Response {
+	return &textResponse{response: newResponse(statusCode, opts), contentType: "text/html; charset=utf-8", text: html}
+}
+
+// TextResponse 返回纯文本格式的 Response
+func TextResponse(statusCode int, text string, opts ...ResponseOption) Response {
+	return &textResponse{response: newResponse(statusCode, opts), contentType: "text/plain; charset=utf-8", text: text}
+}
+
+type redirectResponse struct {
+	response
+	url string
+}
+
+func (r *redirectResponse) WriteTo(w http.ResponseWriter) error {
+	if r.statusCode < 300 || r.statusCode > 399 {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("invalid redirect status code %d", r.statusCode)
+	}
+	w.Header().Set(HeaderLocation, r.url)
+	r.writeHeader(w, "", 0)
+	return nil
+}
+
+var _ Response = &redirectResponse{}
+
+// RedirectResponse 返回重定向的 Response
+//
+// 	statusCode 需要是 3xx 的状态码，如 http.StatusFound，否则写出 500 并返回错误
+func RedirectResponse(statusCode int, url string, opts ...ResponseOption) Response {
+	return &redirectResponse{response: newResponse(statusCode, opts), url: url}
+}
+
+// RedirectToRoute 返回重定向到命名路由的 Response，url 由 r.URL 生成
+//
+// 	如 return seed.RedirectToRoute(r, http.StatusFound, "user", "id", "42")
+// 	生成 url 失败时返回 500 的文本 Response
+func RedirectToRoute(r Router, statusCode int, name string, params ...string) Response {
+	var u, err = r.URL(name, params...)
+	if err != nil {
+		return TextResponse(http.StatusInternalServerError, err.Error())
+	}
+	return RedirectResponse(statusCode, u)
 }
 
-var _ Response = &htmlResponse{}
+type fileResponse struct {
+	response
+	path string
+}
 
-func HtmlResponse(statusCode int, html string) Response {
-	return &htmlResponse{statusCode: statusCode, html: html}
+func (f *fileResponse) WriteTo(w http.ResponseWriter) error {
+	var req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	return f.serve(w, req)
 }
 
+// serve 通过 http.ServeContent 写出文件，支持 Range、If-Modified-Since 等条件请求
+func (f *fileResponse) serve(w http.ResponseWriter, req *http.Request) error {
+	var file, err = os.Open(f.path)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	var info os.FileInfo
+	if info, err = file.Stat(); err != nil || info.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		if err == nil {
+			err = fmt.Errorf("'%s' is a directory", f.path)
+		}
+		return err
+	}
+
+	var contentType = mime.TypeByExtension(filepath.Ext(f.path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	f.addHeader(w)
+	writeHeaderIfNot(w.Header(), contentType, "")
+	http.ServeContent(w, req, info.Name(), info.ModTime(), file)
+	return nil
+}
+
+var _ requestResponse = &fileResponse{}
+
+// FileResponse 返回本地文件内容
+//
+// 	Content-Type 根据文件扩展名推断，需要下载时可以配合 WithAttachment 使用
+// 	经 HandlerFunc 写出时支持 Range、If-Modified-Since 等请求，并设置 Last-Modified
+func FileResponse(path string, opts ...ResponseOption) Response {
+	return &fileResponse{response: newResponse(http.StatusOK, opts), path: path}
+}
+
+// streamChunkSize StreamResponse 每次读取并 Flush 的最大字节数
+const streamChunkSize = 32 << 10
+
+type streamResponse struct {
+	response
+	contentType string
+	reader      io.Reader
+}
+
+func (s *streamResponse) WriteTo(w http.ResponseWriter) error {
+	if c, ok := s.reader.(io.Closer); ok {
+		defer func() { _ = c.Close() }()
+	}
+	s.writeHeader(w, s.contentType, -1)
+
+	var flusher, _ = w.(http.Flusher)
+	var buf = make([]byte, streamChunkSize)
+	for {
+		var n, err = s.reader.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+var _ Response = &streamResponse{}
+
+// StreamResponse 以流的方式把 reader 中的内容写出，长度未知所以不会设置 Content-Length
+//
+// 	每读到一块数据就写出并 Flush，reader 若实现了 io.Closer，写出完成后会被关闭
+func StreamResponse(statusCode int, contentType string, reader io.Reader, opts ...ResponseOption) Response {
+	return &streamResponse{response: newResponse(statusCode, opts), contentType: contentType, reader: reader}
+}
+
+// writeHeaderIfNot 设置 Content-Type(未设置时) 与 Content-Length
+//
+// 	contentType 或 contentLen 为空时不设置对应的 Header
 func writeHeaderIfNot(h http.Header, contentType, contentLen string) {
-	if _, has := h[HeaderContentType]; !has {
+	if _, has := h[HeaderContentType]; !has && contentType != "" {
 		h[HeaderContentType] = []string{contentType}
 	}
-	h[HeaderContentLength] = []string{contentLen}
+	if contentLen != "" {
+		h[HeaderContentLength] = []string{contentLen}
+	}
 }
@@ -0,0 +1,230 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func writeResponse(t *testing.T, r Response) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+	var w = httptest.NewRecorder()
+	var err = r.WriteTo(w)
+	return w, err
+}
+
+func TestResponses(t *testing.T) {
+	var cases = []struct {
+		name        string
+		resp        Response
+		status      int
+		contentType string
+		body        string
+	}{
+		{"json", JsonResponse(http.StatusNotFound, map[string]string{"a": "b"}), http.StatusNotFound, "application/json; charset=utf-8", `{"a":"b"}`},
+		{"html", HtmlResponse(http.StatusCreated, "<p>hi</p>"), http.StatusCreated, "text/html; charset=utf-8", "<p>hi</p>"},
+		{"text", TextResponse(http.StatusBadRequest, "bad"), http.StatusBadRequest, "text/plain; charset=utf-8", "bad"},
+		{"xml", XMLResponse(http.StatusOK, struct {
+			XMLName struct{} `xml:"user"`
+			Name    string   `xml:"name"`
+		}{Name: "alice"}), http.StatusOK, "application/xml; charset=utf-8", `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+		{"nop", NopResponse(http.StatusNoContent), http.StatusNoContent, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var w, err = writeResponse(t, c.resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != c.status || w.Header().Get(HeaderContentType) != c.contentType || w.Body.String() != c.body {
+				t.Fatalf("unexpected response %d %q %q", w.Code, w.Header().Get(HeaderContentType), w.Body.String())
+			}
+			if c.body != "" && w.Header().Get(HeaderContentLength) != strconv.Itoa(len(c.body)) {
+				t.Fatalf("unexpected Content-Length %q", w.Header().Get(HeaderContentLength))
+			}
+		})
+	}
+}
+
+func TestResponseOptions(t *testing.T) {
+	var opts = []ResponseOption{
+		WithHeader("X-Trace", "1"),
+		WithHeader("X-Trace", "2"),
+		WithCookie(&http.Cookie{Name: "sid", Value: "s1"}),
+	}
+	for name, resp := range map[string]Response{
+		"json":     JsonResponse(http.StatusOK, nil, opts...),
+		"text":     TextResponse(http.StatusOK, "", opts...),
+		"nop":      NopResponse(http.StatusOK, opts...),
+		"redirect": RedirectResponse(http.StatusFound, "/", opts...),
+	} {
+		var w, _ = writeResponse(t, resp)
+		if got := w.Header().Values("X-Trace"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+			t.Errorf("%s: unexpected headers %v", name, got)
+		}
+		if cs := w.Result().Cookies(); len(cs) != 1 || cs[0].Name != "sid" || cs[0].Value != "s1" {
+			t.Errorf("%s: unexpected cookies %v", name, cs)
+		}
+	}
+
+	// Content-Type set by an option wins over the default
+	var w, _ = writeResponse(t, TextResponse(http.StatusOK, "a,b", WithHeader(HeaderContentType, "text/csv")))
+	if w.Header().Get(HeaderContentType) != "text/csv" {
+		t.Fatalf("unexpected Content-Type %q", w.Header().Get(HeaderContentType))
+	}
+}
+
+func TestRedirectResponse(t *testing.T) {
+	var w, err = writeResponse(t, RedirectResponse(http.StatusSeeOther, "/login?next=%2F"))
+	if err != nil || w.Code != http.StatusSeeOther || w.Header().Get(HeaderLocation) != "/login?next=%2F" {
+		t.Fatalf("unexpected redirect %d %q %v", w.Code, w.Header().Get(HeaderLocation), err)
+	}
+
+	// an invalid status is reported when writing instead of panicking in the handler
+	w, err = writeResponse(t, RedirectResponse(http.StatusOK, "/"))
+	if err == nil || w.Code != http.StatusInternalServerError || w.Header().Get(HeaderLocation) != "" {
+		t.Fatalf("want 500 and error for invalid status, got %d %v", w.Code, err)
+	}
+}
+
+func TestFileResponse(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var w, err = writeResponse(t, FileResponse(path, WithAttachment("报表.csv")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n" || w.Header().Get(HeaderContentLength) != "4" ||
+		!strings.HasPrefix(w.Header().Get(HeaderContentType), "text/csv") {
+		t.Fatalf("unexpected file response %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+	if cd := w.Header().Get(HeaderContentDisposition); !strings.HasPrefix(cd, "attachment;") || !strings.Contains(cd, "filename*=utf-8''") {
+		t.Fatalf("unexpected Content-Disposition %q", cd)
+	}
+
+	for _, p := range []string{filepath.Join(dir, "missing"), dir} {
+		if w, err = writeResponse(t, FileResponse(p)); err == nil || w.Code != http.StatusNotFound {
+			t.Fatalf("%s: want 404 and error, got %d %v", p, w.Code, err)
+		}
+	}
+}
+
+func TestFileResponseConditional(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var modTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	var r = NewRouter()
+	r.HandleFunc(http.MethodGet, "/report", func(ctx context.Context, req Request) Response {
+		return FileResponse(path, WithHeader("X-Trace", "1"))
+	})
+	var get = func(header, value string) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(http.MethodGet, "/report", nil)
+		req.Header.Set(header, value)
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var w = get("Range", "bytes=2-")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "b\n" || w.Header().Get(HeaderContentLength) != "2" ||
+		w.Header().Get("Content-Range") != "bytes 2-3/4" || w.Header().Get("X-Trace") != "1" {
+		t.Fatalf("unexpected range response %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+	if lm := w.Header().Get("Last-Modified"); lm != modTime.Format(http.TimeFormat) {
+		t.Fatalf("unexpected Last-Modified %q", lm)
+	}
+
+	w = get("If-Modified-Since", modTime.Format(http.TimeFormat))
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("want 304, got %d %q", w.Code, w.Body.String())
+	}
+	w = get("If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat))
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n" {
+		t.Fatalf("want 200, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStreamResponse(t *testing.T) {
+	var rc = &closeRecorder{Reader: strings.NewReader("data: 1\n\n")}
+	var w, err = writeResponse(t, StreamResponse(http.StatusAccepted, "text/event-stream", rc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusAccepted || w.Header().Get(HeaderContentType) != "text/event-stream" || w.Body.String() != "data: 1\n\n" {
+		t.Fatalf("unexpected stream response %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+	if _, has := w.Header()[HeaderContentLength]; has {
+		t.Fatalf("stream response must not set Content-Length")
+	}
+	if !rc.closed {
+		t.Fatalf("want reader closed after writing")
+	}
+}
+
+// flushRecorder records the body written before every Flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	chunks []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.chunks = append(f.chunks, f.Body.String())
+	f.ResponseRecorder.Flush()
+}
+
+func TestStreamResponseFlushes(t *testing.T) {
+	var w = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	if err := StreamResponse(http.StatusOK, "text/plain", iotest.OneByteReader(strings.NewReader("abc"))).WriteTo(w); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(w.chunks, ",") != "a,ab,abc" {
+		t.Fatalf("want a flush per chunk, got %q", w.chunks)
+	}
+
+	var boom = errors.New("boom")
+	w = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	var reader = io.MultiReader(strings.NewReader("a"), iotest.ErrReader(boom))
+	if err := StreamResponse(http.StatusOK, "text/plain", reader).WriteTo(w); !errors.Is(err, boom) || w.Body.String() != "a" {
+		t.Fatalf("want read error after the first chunk, got %v %q", err, w.Body.String())
+	}
+}
+
+func TestResponseThroughRouter(t *testing.T) {
+	var r = NewRouter()
+	r.HandleFunc(http.MethodGet, "/missing", func(ctx context.Context, req Request) Response {
+		return JsonResponse(http.StatusNotFound, map[string]string{"msg": "not found"})
+	})
+	var w = serve(r, http.MethodGet, "/missing")
+	if w.Code != http.StatusNotFound || w.Header().Get(HeaderContentType) != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get(HeaderContentType))
+	}
+}
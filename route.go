@@ -0,0 +1,256 @@
+package seed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Route 注册的路由，由 Route/RouteFunc 返回
+//
+// 	可以链式地补充文档信息，用于生成 OpenAPI 文档，如:
+// 	r.RouteFunc(http.MethodPost, "/users", createUser).
+// 		Name("createUser").
+// 		Summary("创建用户").
+// 		Tags("user").
+// 		Request(CreateUser{}).
+// 		Response(User{})
+// 	HandleStd/Route 注册的 handler 实现了 TypedHandler(如 typed.New)时自动使用其输入输出类型
+type Route struct {
+	registry *routeRegistry
+
+	name        string
+	methods     []string
+	path        string
+	prefix      string
+	handlerName string
+	middlewares []string
+
+	summary     string
+	description string
+	tags        []string
+	operationID string
+	deprecated  bool
+	request     reflect.Type
+	response    reflect.Type
+}
+
+// Name 设置路由名称，用于 Router.URL 反向生成 url，名称重复时 panic
+func (rt *Route) Name(name string) *Route {
+	rt.registry.setName(rt, name)
+	rt.name = name
+	return rt
+}
+
+// Summary 设置接口摘要
+func (rt *Route) Summary(summary string) *Route {
+	rt.summary = summary
+	return rt
+}
+
+// Description 设置接口的详细说明
+func (rt *Route) Description(description string) *Route {
+	rt.description = description
+	return rt
+}
+
+// Tags 追加接口标签，OpenAPI 文档中按标签分组
+func (rt *Route) Tags(tags ...string) *Route {
+	rt.tags = append(rt.tags, tags...)
+	return rt
+}
+
+// OperationID 设置 OpenAPI 的 operationId，注册了多个方法时会追加方法名
+func (rt *Route) OperationID(id string) *Route {
+	rt.operationID = id
+	return rt
+}
+
+// Deprecated 标记接口已废弃
+func (rt *Route) Deprecated() *Route {
+	rt.deprecated = true
+	return rt
+}
+
+// Request 设置请求参数的结构，v 为结构体或结构体指针
+//
+// 	字段按 bind 的规则使用 path/query/header/cookie/json/form tag，校验规则取自 validate tag
+func (rt *Route) Request(v interface{}) *Route {
+	rt.request = reflect.TypeOf(v)
+	return rt
+}
+
+// Response 设置响应数据的结构
+func (rt *Route) Response(v interface{}) *Route {
+	rt.response = reflect.TypeOf(v)
+	return rt
+}
+
+// RouteInfo 注册的路由的一个方法，由 Router.Routes 返回
+type RouteInfo struct {
+	// Name 路由名称，没有设置时为空
+	Name string `json:"name,omitempty"`
+
+	// Method http 方法
+	Method string `json:"method"`
+
+	// Pattern 完整的路由模板，包含分组前缀，如 /user/:id
+	Pattern string `json:"pattern"`
+
+	// Prefix 所在分组的前缀，不在分组中时为空
+	Prefix string `json:"prefix,omitempty"`
+
+	// Handler handler 的名称，如 main.getUser
+	Handler string `json:"handler"`
+
+	// Middlewares 生效的中间件名称，包含分组与该路由特有的中间件
+	Middlewares []string `json:"middlewares,omitempty"`
+
+	// MiddlewareCount 生效的中间件数量
+	MiddlewareCount int `json:"middleware_count"`
+}
+
+func (rt *Route) info(method string) RouteInfo {
+	return RouteInfo{
+		Name:            rt.name,
+		Method:          method,
+		Pattern:         rt.path,
+		Prefix:          rt.prefix,
+		Handler:         rt.handlerName,
+		Middlewares:     append([]string(nil), rt.middlewares...),
+		MiddlewareCount: len(rt.middlewares),
+	}
+}
+
+// RoutesHandler 返回输出路由表的 handler，用于调试
+//
+// 	默认输出文本表格，带有 ?format=json 或 Accept 为 application/json 时输出 json，如:
+// 	s.HandleStd("GET", "/debug/routes", seed.RoutesHandler(s))
+func RoutesHandler(r Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var routes = r.Routes()
+		if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set(HeaderContentType, "application/json; charset=utf-8")
+			if routes == nil {
+				routes = []RouteInfo{}
+			}
+			_ = json.NewEncoder(w).Encode(routes)
+			return
+		}
+
+		w.Header().Set(HeaderContentType, "text/plain; charset=utf-8")
+		var tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARES")
+		for _, ri := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", ri.Method, ri.Pattern, ri.Handler, ri.MiddlewareCount)
+		}
+		_ = tw.Flush()
+	})
+}
+
+// routeRegistry 根路由上记录的所有路由，分组共享
+type routeRegistry struct {
+	mu     sync.Mutex
+	routes []*Route
+	names  map[string]*Route
+}
+
+func (rr *routeRegistry) add(rt *Route) {
+	rr.mu.Lock()
+	rt.registry = rr
+	rr.routes = append(rr.routes, rt)
+	rr.mu.Unlock()
+}
+
+func (rr *routeRegistry) setName(rt *Route, name string) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if other, ok := rr.names[name]; ok && other != rt {
+		panic(fmt.Sprintf("duplicate route name '%s' for path '%s', already used by '%s'", name, rt.path, other.path))
+	}
+	if rr.names == nil {
+		rr.names = map[string]*Route{}
+	}
+	delete(rr.names, rt.name)
+	rr.names[name] = rt
+}
+
+func (rr *routeRegistry) named(name string) *Route {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.names[name]
+}
+
+// ErrRouteNotFound 没有指定名称的路由
+var ErrRouteNotFound = errors.New("route not found")
+
+// buildURL 用 params 填充路由模板中的参数，params 为 name、value 交替的列表
+//
+// 	:param 的值整体转义，*catchall 的值按 / 分段转义
+func buildURL(pattern string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("odd number of params for '%s'", pattern)
+	}
+	var values = make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	var segs = strings.Split(pattern, "/")
+	for i, seg := range segs {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		var name = seg[1:]
+		var v, ok = values[name]
+		if !ok || (seg[0] == ':' && v == "") {
+			return "", fmt.Errorf("%w: '%s' of '%s'", ErrParamNotFound, name, pattern)
+		}
+		delete(values, name)
+		if seg[0] == ':' {
+			segs[i] = url.PathEscape(v)
+			continue
+		}
+		// httprouter 中 catchall 的值以 / 开头
+		var parts = strings.Split(strings.TrimPrefix(v, "/"), "/")
+		for j := range parts {
+			parts[j] = url.PathEscape(parts[j])
+		}
+		segs[i] = strings.Join(parts, "/")
+	}
+	for name := range values {
+		return "", fmt.Errorf("unknown param '%s' for '%s'", name, pattern)
+	}
+	return strings.Join(segs, "/"), nil
+}
+
+// list 返回注册的路由的副本，按注册顺序
+func (rr *routeRegistry) list() []*Route {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]*Route(nil), rr.routes...)
+}
+
+// funcName 返回函数的完整名称，如 main.createUser，非函数时返回类型名
+func funcName(v interface{}) string {
+	var rv = reflect.ValueOf(v)
+	if rv.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", v)
+	}
+	if rv.IsNil() {
+		return ""
+	}
+	var f = runtime.FuncForPC(rv.Pointer())
+	if f == nil {
+		return ""
+	}
+	// 方法值的名称以 -fm 结尾
+	return strings.TrimSuffix(f.Name(), "-fm")
+}
@@ -0,0 +1,113 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func listUsers(ctx context.Context, req Request) Response { return nil }
+
+func TestRoutes(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("a", "1"))
+	r.HandleStd("GET,POST", "/ping", http.NotFoundHandler())
+	r.Group("/api", func(r Router) {
+		r.HandleFunc(http.MethodGet, "/users", listUsers, withValue("b", "2"))
+	}, withValue("c", "3"))
+
+	var routes = r.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("want 3 routes, got %+v", routes)
+	}
+	if routes[0].Method != http.MethodGet || routes[1].Method != http.MethodPost || routes[0].Pattern != "/ping" || routes[0].MiddlewareCount != 1 {
+		t.Fatalf("unexpected routes %+v", routes[:2])
+	}
+	var users = routes[2]
+	if users.Pattern != "/api/users" || users.Prefix != "/api" || users.MiddlewareCount != 3 || !strings.HasSuffix(users.Handler, ".listUsers") {
+		t.Fatalf("unexpected route %+v", users)
+	}
+
+	var stop = errors.New("stop")
+	var n int
+	if err := r.Walk(func(ri RouteInfo) error {
+		if n++; ri.Prefix == "/api" {
+			return stop
+		}
+		return nil
+	}); err != stop || n != 3 {
+		t.Fatalf("walk returned %v after %d routes", err, n)
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	var r = NewRouter()
+	r.HandleFunc(http.MethodGet, "/users/:id", listUsers)
+	r.HandleStd(http.MethodGet, "/debug/routes", RoutesHandler(r))
+
+	var w = serve(r, http.MethodGet, "/debug/routes")
+	if !strings.HasPrefix(w.Body.String(), "METHOD") || !strings.Contains(w.Body.String(), "/users/:id") {
+		t.Fatalf("unexpected table %s", w.Body.String())
+	}
+
+	w = serve(r, http.MethodGet, "/debug/routes?format=json")
+	var routes []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil || len(routes) != 2 || routes[1].Pattern != "/debug/routes" {
+		t.Fatalf("unexpected json %s", w.Body.String())
+	}
+}
+
+func TestURL(t *testing.T) {
+	var r = NewRouter()
+	r.Group("/t/:tenant", func(r Router) {
+		r.RouteFunc(http.MethodGet, "/users/:id", listUsers).Name("user")
+	})
+	r.RouteFunc(http.MethodGet, "/files/*path", listUsers).Name("file")
+
+	var cases = []struct {
+		name   string
+		params []string
+		want   string
+		err    error
+	}{
+		{"user", []string{"tenant", "acme", "id", "a b/c"}, "/t/acme/users/a%20b%2Fc", nil},
+		{"file", []string{"path", "/docs/read me.md"}, "/files/docs/read%20me.md", nil},
+		{"file", []string{"path", ""}, "/files/", nil},
+		{"user", []string{"tenant", "acme"}, "", ErrParamNotFound},
+		{"user", []string{"tenant", "acme", "id", ""}, "", ErrParamNotFound},
+		{"missing", nil, "", ErrRouteNotFound},
+	}
+	for _, c := range cases {
+		var u, err = r.URL(c.name, c.params...)
+		if u != c.want || !errors.Is(err, c.err) {
+			t.Errorf("URL(%s, %v) = %q, %v; want %q, %v", c.name, c.params, u, err, c.want, c.err)
+		}
+	}
+	if _, err := r.URL("user", "tenant", "acme", "id", "1", "extra", "x"); err == nil {
+		t.Errorf("want error for unknown param")
+	}
+	if routes := r.Routes(); routes[0].Name != "user" {
+		t.Errorf("unexpected route name %q", routes[0].Name)
+	}
+
+	r.HandleFunc(http.MethodGet, "/go", func(ctx context.Context, req Request) Response {
+		return RedirectToRoute(r, http.StatusFound, "user", "tenant", "acme", "id", "42")
+	})
+	if w := serve(r, http.MethodGet, "/go"); w.Code != http.StatusFound || w.Header().Get(HeaderLocation) != "/t/acme/users/42" {
+		t.Errorf("unexpected redirect %d %q", w.Code, w.Header().Get(HeaderLocation))
+	}
+}
+
+func TestDuplicateRouteName(t *testing.T) {
+	var r = NewRouter()
+	r.RouteFunc(http.MethodGet, "/a", listUsers).Name("x")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want panic for duplicate route name")
+		}
+	}()
+	r.RouteFunc(http.MethodGet, "/b", listUsers).Name("x")
+}
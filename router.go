@@ -42,6 +42,7 @@ type Router interface {
 	// 	也可以用特殊的 ANY,会自动注册所有( ANY 的取值详见 MethodAny )
 	// 	handler 是业务的逻辑
 	// 	ms 是该接口特有的中间件函数
+	// 	需要设置路由名称或补充文档信息时使用 Route
 	HandleStd(methods string, path string, handler http.Handler, ms ...MiddlewareFunc)
 
 	// HandleFunc 以HandlerFunc方式注册业务handler
@@ -50,8 +51,18 @@ type Router interface {
 	// 	也可以用特殊的 ANY,会自动注册所有( ANY 的取值详见 MethodAny )
 	// 	handler 是业务的逻辑
 	// 	ms 是该接口特有的中间件函数
+	// 	需要设置路由名称或补充文档信息时使用 RouteFunc
 	HandleFunc(methods string, path string, handlerFunc HandlerFunc, ms ...MiddlewareFunc)
 
+	// Route 同 HandleStd，返回注册的 *Route
+	//
+	// 	可以链式地设置路由名称(用于 URL)、补充文档信息(用于 OpenAPI)，如:
+	// 	r.Route(http.MethodGet, "/files/*path", files).Name("file").Tags("file")
+	Route(methods string, path string, handler http.Handler, ms ...MiddlewareFunc) *Route
+
+	// RouteFunc 同 HandleFunc，返回注册的 *Route，用法同 Route
+	RouteFunc(methods string, path string, handlerFunc HandlerFunc, ms ...MiddlewareFunc) *Route
+
 	// Group 路由分组
 	//
 	// 	如 可以将 /user/xxx 系列分成一个分组
@@ -59,16 +70,91 @@ type Router interface {
 	// 	ms 是该分组的中间件函数
 	Group(prefix string, f func(r Router), ms ...MiddlewareFunc)
 
+	// Routes 返回所有分组中注册的路由，每个方法一条，按注册顺序
+	Routes() []RouteInfo
+
+	// Walk 按 Routes 的顺序遍历路由，fn 返回错误时停止遍历并返回该错误
+	Walk(fn func(RouteInfo) error) error
+
+	// URL 根据路由名称生成路径，名称通过 Route.Name 设置
+	//
+	// 	params 为参数名与值交替的列表，如 r.URL("user", "id", "42") 得到 /user/42
+	// 	参数值会被转义，*catchall 参数中的 / 保留
+	// 	路由不存在时返回 ErrRouteNotFound，缺少参数时返回 ErrParamNotFound
+	URL(name string, params ...string) (string, error)
+
 	// notFound 设置全局404状态处理器
 	notFound(http.Handler)
 
+	// methodNotAllowed 设置全局405状态处理器
+	methodNotAllowed(http.Handler)
+
 	// 静态资源
 	static(path string, root http.FileSystem)
+
+	// registeredRoutes 返回所有分组中注册的路由
+	registeredRoutes() []*Route
+}
+
+// RouterOption 路由器配置项
+type RouterOption func(o *routerOptions)
+
+type routerOptions struct {
+	handleMethodNotAllowed bool
+	handleOPTIONS          bool
+	handleHEAD             bool
+
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+	options          http.Handler
+}
+
+// WithMethodNotAllowed 路径存在但方法不匹配时是否返回 405，默认开启
+//
+// 	开启时会附带 Allow Header 列出该路径已注册的方法
+func WithMethodNotAllowed(enable bool) RouterOption {
+	return func(o *routerOptions) {
+		o.handleMethodNotAllowed = enable
+	}
+}
+
+// WithAutoOPTIONS 是否自动应答未注册 OPTIONS 方法的路径，默认开启
+//
+// 	应答会附带 Allow Header 列出该路径已注册的方法
+func WithAutoOPTIONS(enable bool) RouterOption {
+	return func(o *routerOptions) {
+		o.handleOPTIONS = enable
+	}
+}
+
+// WithOPTIONSHandler 设置自动应答 OPTIONS 请求的处理器，默认返回 204
+//
+// 	调用 h 之前 Allow Header 已经设置好
+func WithOPTIONSHandler(h http.Handler) RouterOption {
+	return func(o *routerOptions) {
+		o.options = h
+	}
+}
+
+// WithAutoHEAD 未注册 HEAD 方法的路径是否自动使用 GET 的 handler 处理，默认开启
+func WithAutoHEAD(enable bool) RouterOption {
+	return func(o *routerOptions) {
+		o.handleHEAD = enable
+	}
 }
 
 type router struct {
 	*HRouter.Router
 
+	// root 根路由，分组共享
+	root *router
+
+	// opts 路由器配置，分组共享
+	opts *routerOptions
+
+	// registry 注册的路由，分组共享
+	registry *routeRegistry
+
 	// prefix 路由前缀
 	//
 	// 	用于新建路由组等情况暂存前缀信息
@@ -88,24 +174,50 @@ func (r *router) Group(prefix string, f func(r Router), ms ...MiddlewareFunc) {
 	mws = append(mws, ms...)
 
 	//make new router prefix
-	var router = &router{Router: r.Router, middlewareFuncs: mws, prefix: r.prefix + prefix}
+	var router = &router{Router: r.Router, root: r.root, opts: r.opts, registry: r.registry, middlewareFuncs: mws, prefix: r.prefix + prefix}
 	f(router)
 }
 
 func (r *router) HandleFunc(methods string, path string, handlerFunc HandlerFunc, ms ...MiddlewareFunc) {
-	r.HandleStd(methods, path, handlerFunc.Handler(), ms...)
+	r.RouteFunc(methods, path, handlerFunc, ms...)
 }
 
 func (r *router) HandleStd(methods string, mpath string, handler http.Handler, ms ...MiddlewareFunc) {
-	var h = r.Trans2Handle(handler, ms...)
-	var mss = strings.Split(methods, MethodSep)
+	r.Route(methods, mpath, handler, ms...)
+}
+
+func (r *router) RouteFunc(methods string, path string, handlerFunc HandlerFunc, ms ...MiddlewareFunc) *Route {
+	return r.handle(methods, path, handlerFunc.Handler(), funcName(handlerFunc), ms)
+}
+
+func (r *router) Route(methods string, mpath string, handler http.Handler, ms ...MiddlewareFunc) *Route {
+	return r.handle(methods, mpath, handler, funcName(handler), ms)
+}
+
+// handle 注册路由并记录到 registry 中，name 为 handler 的名称
+func (r *router) handle(methods string, mpath string, handler http.Handler, name string, ms []MiddlewareFunc) *Route {
 	var apath = path.Clean(fmt.Sprintf("%s%s", r.prefix, mpath))
+	var h = r.trans2Handle(apath, handler, ms...)
+	var mss = strings.Split(methods, MethodSep)
 	for _, v := range mss {
 		if slices.Index(allowedMethods, v) == -1 {
 			panic(fmt.Sprintf("invalid router method '%s' for path '%s'", v, apath))
 		}
 		r.Handle(v, apath, h)
 	}
+
+	var rt = &Route{methods: mss, path: apath, prefix: r.prefix, handlerName: name}
+	for _, m := range r.middlewareFuncs {
+		rt.middlewares = append(rt.middlewares, funcName(m))
+	}
+	for _, m := range ms {
+		rt.middlewares = append(rt.middlewares, funcName(m))
+	}
+	if th, ok := handler.(TypedHandler); ok {
+		rt.request, rt.response = th.IOTypes()
+	}
+	r.registry.add(rt)
+	return rt
 }
 
 func (r *router) Use(ms ...MiddlewareFunc) Router {
@@ -116,34 +228,162 @@ func (r *router) Use(ms ...MiddlewareFunc) Router {
 }
 
 func (r *router) Trans2Handle(h http.Handler, ms ...MiddlewareFunc) HRouter.Handle {
-	ms = append(r.middlewareFuncs, ms...)
-	var f = func(w http.ResponseWriter, r *http.Request, pr HRouter.Params) {
-		var mw MiddlewareFunc = func(ctx context.Context, ww http.ResponseWriter, rr *http.Request, next MiddleWareQueue) bool {
-			h.ServeHTTP(ww, rr)
-			return false
-		}
+	return r.trans2Handle("", h, ms...)
+}
 
-		var mws MiddlewareFuncs = append(ms, mw)
+// trans2Handle 组装中间件与业务 handler，pattern 为完整的路由模板，会放入请求的 context 中
+func (r *router) trans2Handle(pattern string, h http.Handler, ms ...MiddlewareFunc) HRouter.Handle {
+	// 业务 handler 作为最后一个中间件，rr 已经由 Next 挂上了链路中的 ctx
+	var mw MiddlewareFunc = func(ctx context.Context, ww http.ResponseWriter, rr *http.Request, next MiddleWareQueue) bool {
+		h.ServeHTTP(ww, rr)
+		return false
+	}
+
+	// 复制一份，避免与分组或其他路由共用底层数组
+	var mws = make(MiddlewareFuncs, 0, len(r.middlewareFuncs)+len(ms)+1)
+	mws = append(mws, r.middlewareFuncs...)
+	mws = append(mws, ms...)
+	mws = append(mws, mw)
+
+	var f = func(w http.ResponseWriter, r *http.Request, pr HRouter.Params) {
+		r = withRoute(r, pattern, pr)
 		mws.Next(r.Context(), w, r)
 	}
 	return f
 }
 
+func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodHead && r.opts.handleHEAD {
+		if h, _, _ := r.Lookup(http.MethodHead, req.URL.Path); h == nil {
+			if h, ps, _ := r.Lookup(http.MethodGet, req.URL.Path); h != nil {
+				h(w, req, ps)
+				return
+			}
+		}
+	}
+	r.Router.ServeHTTP(w, req)
+}
+
+// fallback 没有匹配到路由时的处理：自动 OPTIONS 应答、405 或 404
+//
+// 	自动 OPTIONS 应答与 405 会经过根路由上通过 Use 注册的中间件
+func (r *router) fallback(w http.ResponseWriter, req *http.Request) {
+	var allow = r.allowed(req.URL.Path)
+	if allow != "" {
+		if req.Method == http.MethodOptions && r.opts.handleOPTIONS {
+			w.Header().Set("Allow", allow)
+			var h = r.opts.options
+			if h == nil {
+				h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNoContent)
+				})
+			}
+			r.root.Trans2Handle(h)(w, req, nil)
+			return
+		}
+		if r.opts.handleMethodNotAllowed {
+			w.Header().Set("Allow", allow)
+			var h = r.opts.methodNotAllowed
+			if h == nil {
+				h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				})
+			}
+			r.root.Trans2Handle(h)(w, req, nil)
+			return
+		}
+	}
+
+	var h = r.opts.notFound
+	if h == nil {
+		h = notFound
+	}
+	h.ServeHTTP(w, req)
+}
+
+// allowed 返回 path 已注册的方法，以 ", " 连接，没有时返回空串
+func (r *router) allowed(path string) string {
+	var allow = make([]string, 0, len(allowedMethods))
+	for _, m := range allowedMethods {
+		if h, _, _ := r.Lookup(m, path); h != nil {
+			allow = append(allow, m)
+		}
+	}
+	if len(allow) == 0 {
+		return ""
+	}
+	if r.opts.handleHEAD && slices.Contains(allow, http.MethodGet) && !slices.Contains(allow, http.MethodHead) {
+		allow = slices.Insert(allow, slices.Index(allow, http.MethodGet)+1, http.MethodHead)
+	}
+	if r.opts.handleOPTIONS && !slices.Contains(allow, http.MethodOptions) {
+		allow = append(allow, http.MethodOptions)
+	}
+	return strings.Join(allow, ", ")
+}
+
 func (r *router) notFound(h http.Handler) {
-	r.NotFound = h
+	r.opts.notFound = h
+}
+
+func (r *router) methodNotAllowed(h http.Handler) {
+	r.opts.methodNotAllowed = h
 }
 
 func (r *router) static(path string, root http.FileSystem) {
 	r.ServeFiles(path, root)
+	r.registry.add(&Route{methods: []string{http.MethodGet}, path: path, handlerName: "http.FileServer"})
+}
+
+func (r *router) registeredRoutes() []*Route {
+	return r.registry.list()
 }
 
-func NewRouter() Router {
-	var r = &HRouter.Router{
+func (r *router) Routes() []RouteInfo {
+	var infos []RouteInfo
+	for _, rt := range r.registry.list() {
+		for _, m := range rt.methods {
+			infos = append(infos, rt.info(m))
+		}
+	}
+	return infos
+}
+
+func (r *router) URL(name string, params ...string) (string, error) {
+	var rt = r.registry.named(name)
+	if rt == nil {
+		return "", fmt.Errorf("%w: '%s'", ErrRouteNotFound, name)
+	}
+	return buildURL(rt.path, params...)
+}
+
+func (r *router) Walk(fn func(RouteInfo) error) error {
+	for _, info := range r.Routes() {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRouter 创建路由器，opts 详见 RouterOption
+func NewRouter(opts ...RouterOption) Router {
+	var o = &routerOptions{
+		handleMethodNotAllowed: true,
+		handleOPTIONS:          true,
+		handleHEAD:             true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var r = &router{prefix: "", opts: o, registry: &routeRegistry{}, middlewareFuncs: []MiddlewareFunc{}}
+	r.root = r
+	r.Router = &HRouter.Router{
 		RedirectTrailingSlash:  false,
 		RedirectFixedPath:      false,
 		HandleMethodNotAllowed: false,
 		HandleOPTIONS:          false,
-		NotFound:               notFound,
+		NotFound:               http.HandlerFunc(r.fallback),
 	}
-	return &router{Router: r, prefix: "", middlewareFuncs: []MiddlewareFunc{}}
+	return r
 }
@@ -0,0 +1,174 @@
+package seed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testCtxKey string
+
+func withValue(key testCtxKey, value string) MiddlewareFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, next MiddleWareQueue) bool {
+		return next.Next(context.WithValue(ctx, key, value), w, req)
+	}
+}
+
+func serve(r Router, method, target string) *httptest.ResponseRecorder {
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestMiddlewareContextReachesHandlerFunc(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("user", "alice"))
+	r.HandleFunc(http.MethodGet, "/me", func(ctx context.Context, req Request) Response {
+		var user, _ = ctx.Value(testCtxKey("user")).(string)
+		if v, _ := req.HTTPRequest().Context().Value(testCtxKey("user")).(string); v != user {
+			t.Errorf("request ctx value = %q, handler ctx value = %q", v, user)
+		}
+		return TextResponse(http.StatusOK, user)
+	})
+
+	var w = serve(r, http.MethodGet, "/me")
+	if got := w.Body.String(); got != "alice" {
+		t.Fatalf("want body %q, got %q", "alice", got)
+	}
+}
+
+func TestMiddlewareContextReachesStdHandler(t *testing.T) {
+	var r = NewRouter()
+	r.HandleStd(http.MethodGet, "/me", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var user, _ = req.Context().Value(testCtxKey("user")).(string)
+		_, _ = w.Write([]byte(user))
+	}), withValue("user", "bob"))
+
+	var w = serve(r, http.MethodGet, "/me")
+	if got := w.Body.String(); got != "bob" {
+		t.Fatalf("want body %q, got %q", "bob", got)
+	}
+}
+
+func TestMiddlewareContextAccumulates(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("tenant", "acme"))
+	r.Group("/api", func(g Router) {
+		g.HandleFunc(http.MethodGet, "/me", func(ctx context.Context, req Request) Response {
+			var tenant, _ = ctx.Value(testCtxKey("tenant")).(string)
+			var user, _ = ctx.Value(testCtxKey("user")).(string)
+			return TextResponse(http.StatusOK, tenant+"/"+user)
+		}, withValue("user", "carol"))
+	}, withValue("user", "overridden-by-route"))
+
+	var w = serve(r, http.MethodGet, "/api/me")
+	if got := w.Body.String(); got != "acme/carol" {
+		t.Fatalf("want body %q, got %q", "acme/carol", got)
+	}
+}
+
+func TestMiddlewareSeesEarlierContext(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("user", "dave"))
+	r.Use(func(ctx context.Context, w http.ResponseWriter, req *http.Request, next MiddleWareQueue) bool {
+		if v, _ := req.Context().Value(testCtxKey("user")).(string); v != "dave" {
+			t.Errorf("want request ctx value %q in later middleware, got %q", "dave", v)
+		}
+		return next.Next(ctx, w, req)
+	})
+	r.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req Request) Response {
+		return NopResponse(http.StatusNoContent)
+	})
+
+	if w := serve(r, http.MethodGet, "/"); w.Code != http.StatusNoContent {
+		t.Fatalf("want status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func TestMiddlewareContextKeepsParams(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("user", "erin"))
+	r.HandleFunc(http.MethodGet, "/user/:id", func(ctx context.Context, req Request) Response {
+		var id, _ = req.Param("id")
+		if ParamsFromContext(ctx).ByName("id") != id {
+			t.Errorf("params missing from handler ctx")
+		}
+		return TextResponse(http.StatusOK, id)
+	})
+
+	var w = serve(r, http.MethodGet, "/user/42")
+	if got := w.Body.String(); got != "42" {
+		t.Fatalf("want body %q, got %q", "42", got)
+	}
+}
+
+func TestRouteMiddlewaresDoNotLeakBetweenRoutes(t *testing.T) {
+	var r = NewRouter()
+	r.Use(withValue("tenant", "acme"))
+	var h = func(ctx context.Context, req Request) Response {
+		var user, _ = ctx.Value(testCtxKey("user")).(string)
+		return TextResponse(http.StatusOK, user)
+	}
+	r.HandleFunc(http.MethodGet, "/a", h, withValue("user", "a"))
+	r.HandleFunc(http.MethodGet, "/b", h)
+
+	if got := serve(r, http.MethodGet, "/a").Body.String(); got != "a" {
+		t.Fatalf("want body %q, got %q", "a", got)
+	}
+	if got := serve(r, http.MethodGet, "/b").Body.String(); got != "" {
+		t.Fatalf("want empty body, got %q", got)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	var r = NewRouter()
+	var h = func(ctx context.Context, req Request) Response { return NopResponse(http.StatusOK) }
+	r.HandleFunc("GET,POST", "/user/:id", h)
+
+	var w = serve(r, http.MethodDelete, "/user/1")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if got, want := w.Header().Get("Allow"), "GET, HEAD, POST, OPTIONS"; got != want {
+		t.Fatalf("want Allow %q, got %q", want, got)
+	}
+
+	if w = serve(r, http.MethodDelete, "/missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("want status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestAutoOPTIONS(t *testing.T) {
+	var r = NewRouter(WithOPTIONSHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	r.Use(withValue("user", "frank"))
+	r.HandleFunc(http.MethodPut, "/doc", func(ctx context.Context, req Request) Response { return NopResponse(http.StatusOK) })
+
+	var w = serve(r, http.MethodOptions, "/doc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got, want := w.Header().Get("Allow"), "PUT, OPTIONS"; got != want {
+		t.Fatalf("want Allow %q, got %q", want, got)
+	}
+}
+
+func TestAutoHEAD(t *testing.T) {
+	var r = NewRouter()
+	r.HandleFunc(http.MethodGet, "/ping", func(ctx context.Context, req Request) Response {
+		return TextResponse(http.StatusOK, req.HTTPRequest().Method)
+	})
+	if w := serve(r, http.MethodHead, "/ping"); w.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	r = NewRouter(WithAutoHEAD(false))
+	r.HandleFunc(http.MethodGet, "/ping", func(ctx context.Context, req Request) Response {
+		return NopResponse(http.StatusOK)
+	})
+	if w := serve(r, http.MethodHead, "/ping"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -2,9 +2,24 @@ package seed
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
+// defaultShutdownTimeout 优雅退出时等待处理中请求的默认时长
+const defaultShutdownTimeout = 30 * time.Second
+
+// Hook 启动或退出时执行的钩子函数
+type Hook func(ctx context.Context) error
+
 type MSeed interface {
 	Router
 
@@ -20,6 +35,7 @@ type MSeed interface {
 	// Run 启动HTTPServer
 	//
 	// 	如果需要手动设定启动占用的端口请调用SetHTTPServer
+	// 	调用过 Listen/ListenOn 时同 RunContext(context.Background())
 	Run() error
 
 	// Run 启动HTTPServer
@@ -27,24 +43,101 @@ type MSeed interface {
 	// 	certFile https certFile
 	// 	keyFile https keyFile
 	// 	如果需要手动设定启动占用的端口请调用SetHTTPServer
+	// 	证书文件变化后会自动重新加载，详见 CertReloader
+	// 	HTTPServer 设置了 TLSConfig 时在其基础上使用该证书，否则使用 NewTLSConfig
 	RunTLS(certFile, keyFile string) error
 
+	// SetTLSConfig 以 HTTPS 提供服务，之后的 Run/RunContext/Serve 都使用 cfg
+	//
+	// 	cfg 一般由 NewTLSConfig 创建
+	SetTLSConfig(cfg *tls.Config) MSeed
+
+	// Serve 在已经打开的 l 上提供服务，直到 Shutdown 被调用
+	//
+	// 	用于 socket activation、平滑重启时继承的监听等，钩子与就绪标记同 RunContext
+	Serve(l net.Listener) error
+
+	// Listen 添加一个监听地址，在 Run/RunContext 时打开
+	//
+	// 	network 为 "tcp"、"tcp4"、"tcp6" 或 "unix"，可以多次调用同时监听多个地址，如:
+	// 	s.Listen("tcp", ":80", seed.WithHTTPSRedirect("443")).
+	// 		Listen("tcp", ":443", seed.WithListenCertFile(cert, key)).
+	// 		Listen("unix", "/run/app.sock").
+	// 		Listen("tcp", "127.0.0.1:9090", seed.WithListenHandler(admin))
+	// 	每个地址使用单独的 *http.Server，超时等配置复制自 HTTPServer
+	// 	调用过 Listen/ListenOn 后不再监听 HTTPServer 的 Addr
+	Listen(network, addr string, opts ...ListenOption) MSeed
+
+	// ListenOn 添加一个已经打开的监听，其余同 Listen
+	ListenOn(l net.Listener, opts ...ListenOption) MSeed
+
+	// RunContext 启动HTTPServer，ctx 结束时优雅退出
+	//
+	// 	开始监听后依次执行 OnStart 钩子，全部成功后才开始接受请求并标记为就绪
+	// 	ctx 结束时先取消就绪标记，停止接受新连接并等待处理中的请求完成(最多 SetShutdownTimeout 设定的时长，
+	// 	超时后强制关闭剩余的连接)，然后依次执行 OnShutdown 钩子
+	// 	直接调用 Shutdown 时同样等到其完成(连接排空、钩子执行完)才返回
+	// 	正常退出时返回 nil 而不是 http.ErrServerClosed
+	RunContext(ctx context.Context) error
+
+	// RunWithGracefulShutdown 启动HTTPServer，收到 SIGINT/SIGTERM 时优雅退出
+	//
+	// 	timeout 为本次运行等待处理中请求以及执行 OnShutdown 钩子的最长时间，不影响 SetShutdownTimeout 的设置
+	// 	其余同 RunContext
+	RunWithGracefulShutdown(timeout time.Duration) error
+
+	// OnStart 注册启动钩子，按注册顺序执行，任一返回错误时停止启动
+	OnStart(fn Hook) MSeed
+
+	// OnShutdown 注册退出钩子，在处理中的请求完成之后按注册顺序执行
+	//
+	// 	如关闭数据库连接，ctx 的截止时间独立于等待请求完成，同为 SetShutdownTimeout 设定的时长
+	OnShutdown(fn Hook) MSeed
+
+	// SetShutdownTimeout 设置优雅退出时等待处理中请求、执行 OnShutdown 钩子各自的最长时间，默认 30s
+	SetShutdownTimeout(timeout time.Duration) MSeed
+
+	// Ready 是否就绪: 启动钩子执行完成后为 true，开始退出后为 false
+	Ready() bool
+
+	// ReadyHandler 就绪探针，就绪时返回 200，否则返回 503
+	ReadyHandler() http.Handler
+
 	// Shutdown gracefully shuts down the server
+	//
+	// 	取消就绪标记，等待处理中的请求完成后依次执行 OnShutdown 钩子
+	// 	ctx 结束时强制关闭剩余的连接，钩子使用 SetShutdownTimeout 设定的时长
 	Shutdown(ctx context.Context) error
 
 	// NotFound 注册全局的404处理器
 	NotFound(h http.Handler)
+
+	// MethodNotAllowed 注册全局的405处理器
+	//
+	// 	调用 h 之前 Allow Header 已经设置好
+	MethodNotAllowed(h http.Handler)
 }
 
 // mseed is driven by Router
 type mseed struct {
 	Router
 
-	certFile  string
-	keyFile   string
 	enableTLS bool
 
-	server *http.Server
+	server   *http.Server
+	bindings []*binding
+
+	// running 正在运行的 server，Shutdown 时逐个关闭
+	mu      sync.Mutex
+	running []*serving
+
+	// shutdownDone 最近一次 Shutdown 结束(server 关闭且钩子执行完)时关闭
+	shutdownDone chan struct{}
+
+	onStart         []Hook
+	onShutdown      []Hook
+	shutdownTimeout time.Duration
+	ready           atomic.Bool
 }
 
 func (c *mseed) HTTPServer() *http.Server {
@@ -62,34 +155,267 @@ func (c *mseed) Static(path string, root http.FileSystem) MSeed {
 }
 
 func (c *mseed) Run() error {
+	if len(c.bindings) > 0 {
+		return c.RunContext(context.Background())
+	}
+
 	// set handler as itself
 	c.server.Handler = c
 
 	if c.enableTLS {
-		return c.server.ListenAndServeTLS(c.certFile, c.keyFile)
+		return c.server.ListenAndServeTLS("", "")
 	}
 	return c.server.ListenAndServe()
 }
 
 func (c *mseed) RunTLS(certFile, keyFile string) error {
-	c.certFile = certFile
-	c.keyFile = keyFile
-	c.enableTLS = true
+	var cfg, err = certFileTLSConfig(c.server.TLSConfig, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	c.SetTLSConfig(cfg)
 	return c.Run()
 }
 
+func (c *mseed) SetTLSConfig(cfg *tls.Config) MSeed {
+	c.server.TLSConfig = cfg
+	c.enableTLS = true
+	return c
+}
+
+func (c *mseed) Serve(l net.Listener) error {
+	return c.serveContext(context.Background(), []*serving{{srv: c.server, ln: l, tls: c.enableTLS}}, c.shutdownTimeout)
+}
+
+func (c *mseed) Listen(network, addr string, opts ...ListenOption) MSeed {
+	var b = &binding{network: network, addr: addr}
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	c.bindings = append(c.bindings, b)
+	return c
+}
+
+func (c *mseed) ListenOn(l net.Listener, opts ...ListenOption) MSeed {
+	var b = &binding{network: l.Addr().Network(), addr: l.Addr().String(), ln: l}
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	c.bindings = append(c.bindings, b)
+	return c
+}
+
+func (c *mseed) RunContext(ctx context.Context) error {
+	return c.runContext(ctx, c.shutdownTimeout)
+}
+
+// runContext 同 RunContext，timeout 为退出时等待请求与执行钩子各自的最长时间
+func (c *mseed) runContext(ctx context.Context, timeout time.Duration) error {
+	var ss, err = c.open()
+	if err != nil {
+		return err
+	}
+	return c.serveContext(ctx, ss, timeout)
+}
+
+// open 打开所有监听
+//
+// 	没有调用过 Listen/ListenOn 时使用 HTTPServer 的 Addr 与 RunTLS 设置的证书
+func (c *mseed) open() ([]*serving, error) {
+	if len(c.bindings) == 0 {
+		var addr = c.server.Addr
+		if addr == "" {
+			addr = ":http"
+			if c.enableTLS {
+				addr = ":https"
+			}
+		}
+		var ln, err = net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return []*serving{{srv: c.server, ln: ln, tls: c.enableTLS}}, nil
+	}
+
+	var ss = make([]*serving, 0, len(c.bindings))
+	for _, b := range c.bindings {
+		var s, err = b.serving(c.server, c)
+		if err != nil {
+			for _, s := range ss {
+				_ = s.ln.Close()
+			}
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+// serveContext 在所有 ss 上提供服务直到 ctx 结束、Shutdown 被调用或其中一个出错
+func (c *mseed) serveContext(ctx context.Context, ss []*serving, timeout time.Duration) error {
+	// set handler as itself
+	c.server.Handler = c
+
+	for _, fn := range c.onStart {
+		if err := fn(ctx); err != nil {
+			for _, s := range ss {
+				_ = s.ln.Close()
+			}
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.running = ss
+	c.mu.Unlock()
+
+	var errCh = make(chan error, len(ss))
+	for _, s := range ss {
+		go func(s *serving) { errCh <- s.serve() }(s)
+	}
+	c.ready.Store(true)
+
+	var errs []error
+	var remaining = len(ss)
+	var shutdown bool
+	select {
+	case err := <-errCh:
+		// Shutdown 被直接调用时由其关闭其余的 server 并执行钩子
+		remaining--
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+			shutdown = true
+		}
+	case <-ctx.Done():
+		shutdown = true
+	}
+	if shutdown {
+		var sctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		errs = append(errs, c.shutdown(sctx, timeout))
+	}
+	for ; remaining > 0; remaining-- {
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	// Serve 在 Shutdown 开始时就会返回，等待连接排空与钩子执行完，调用方才能安全退出
+	c.mu.Lock()
+	var done = c.shutdownDone
+	c.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return errors.Join(errs...)
+}
+
+func (c *mseed) RunWithGracefulShutdown(timeout time.Duration) error {
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return c.runContext(ctx, timeout)
+}
+
+func (c *mseed) OnStart(fn Hook) MSeed {
+	c.onStart = append(c.onStart, fn)
+	return c
+}
+
+func (c *mseed) OnShutdown(fn Hook) MSeed {
+	c.onShutdown = append(c.onShutdown, fn)
+	return c
+}
+
+func (c *mseed) SetShutdownTimeout(timeout time.Duration) MSeed {
+	c.shutdownTimeout = timeout
+	return c
+}
+
+func (c *mseed) Ready() bool {
+	return c.ready.Load()
+}
+
+func (c *mseed) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !c.Ready() {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		_ = TextResponse(http.StatusOK, "ok").WriteTo(w)
+	})
+}
+
 func (c *mseed) Shutdown(ctx context.Context) error {
-	return c.HTTPServer().Shutdown(ctx)
+	return c.shutdown(ctx, c.shutdownTimeout)
+}
+
+// shutdown 在 ctx 结束前排空连接，之后强制关闭，OnShutdown 钩子另有 hookTimeout 的时长
+func (c *mseed) shutdown(ctx context.Context, hookTimeout time.Duration) error {
+	c.ready.Store(false)
+
+	var done = make(chan struct{})
+	defer close(done)
+
+	var servers = []*http.Server{c.server}
+	c.mu.Lock()
+	c.shutdownDone = done
+	if len(c.running) > 0 {
+		servers = servers[:0]
+		for _, s := range c.running {
+			servers = append(servers, s.srv)
+		}
+	}
+	c.mu.Unlock()
+
+	// 同时关闭，避免先关闭的 server 等待时其余的仍在接受新请求
+	var errCh = make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			var err = srv.Shutdown(ctx)
+			if ctx.Err() != nil {
+				// 超时后仍未完成的请求不再等待
+				_ = srv.Close()
+			}
+			errCh <- err
+		}(srv)
+	}
+	var errs []error
+	for range servers {
+		errs = append(errs, <-errCh)
+	}
+
+	// 排空可能用完了 ctx 的时间，钩子使用单独的截止时间
+	var hctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), hookTimeout)
+	defer cancel()
+	return errors.Join(append(errs, c.runShutdownHooks(hctx))...)
+}
+
+// runShutdownHooks 依次执行所有 OnShutdown 钩子，返回合并后的错误
+func (c *mseed) runShutdownHooks(ctx context.Context) error {
+	var errs []error
+	for _, fn := range c.onShutdown {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (c *mseed) NotFound(h http.Handler) {
 	c.notFound(h)
 }
 
+func (c *mseed) MethodNotAllowed(h http.Handler) {
+	c.methodNotAllowed(h)
+}
+
 // New return *mseed
-func New() MSeed {
+//
+// 	opts 为路由器配置项，详见 RouterOption
+func New(opts ...RouterOption) MSeed {
 	return &mseed{
-		Router: NewRouter(),
-		server: &http.Server{Addr: ":8080"},
+		Router:          NewRouter(opts...),
+		server:          &http.Server{Addr: ":8080"},
+		shutdownTimeout: defaultShutdownTimeout,
 	}
 }
@@ -0,0 +1,199 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunContextGracefulShutdown(t *testing.T) {
+	var s = New()
+	var events []string
+	var hook = func(name string) Hook {
+		return func(ctx context.Context) error {
+			events = append(events, name)
+			return nil
+		}
+	}
+	s.OnStart(hook("start1")).OnStart(hook("start2"))
+	s.OnShutdown(hook("stop1")).OnShutdown(hook("stop2"))
+
+	var started = make(chan struct{})
+	var release = make(chan struct{})
+	s.HandleFunc(http.MethodGet, "/slow", func(ctx context.Context, req Request) Response {
+		close(started)
+		<-release
+		return TextResponse(http.StatusOK, "done")
+	})
+
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ctx, cancel = context.WithCancel(context.Background())
+	var runErr = make(chan error, 1)
+	go func() { runErr <- s.ListenOn(ln).RunContext(ctx) }()
+
+	var body = make(chan string, 1)
+	go func() {
+		var resp, err = http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		var bs, _ = io.ReadAll(resp.Body)
+		body <- string(bs)
+	}()
+
+	<-started
+	if !s.Ready() {
+		t.Fatalf("want ready while serving")
+	}
+	cancel()
+	waitFor(t, func() bool { return !s.Ready() })
+	var w = httptest.NewRecorder()
+	s.ReadyHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want status %d while draining, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	close(release)
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request not drained: %q", got)
+	}
+	if err = <-runErr; err != nil {
+		t.Fatalf("want nil on clean shutdown, got %v", err)
+	}
+	if got, want := strings.Join(events, ","), "start1,start2,stop1,stop2"; got != want {
+		t.Fatalf("want hooks %q, got %q", want, got)
+	}
+}
+
+// waitFor 轮询 cond 直到成立，超时则失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	var deadline = time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunContextWaitsForShutdown(t *testing.T) {
+	var s = New()
+	var entered = make(chan struct{})
+	var release = make(chan struct{})
+	var finished atomic.Bool
+	s.OnShutdown(func(ctx context.Context) error {
+		close(entered)
+		<-release
+		finished.Store(true)
+		return nil
+	})
+
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runErr = make(chan error, 1)
+	go func() { runErr <- s.Serve(ln) }()
+	waitFor(t, s.Ready)
+
+	var shutdownErr = make(chan error, 1)
+	go func() { shutdownErr <- s.Shutdown(context.Background()) }()
+	<-entered
+	select {
+	case err = <-runErr:
+		t.Fatalf("Serve returned %v before shutdown hooks finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err = <-runErr; err != nil || !finished.Load() {
+		t.Fatalf("want nil after hooks finished, got %v (finished %v)", err, finished.Load())
+	}
+	if err = <-shutdownErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunContextShutdownTimeout(t *testing.T) {
+	var s = New()
+	var release = make(chan struct{})
+	defer close(release)
+	var started = make(chan struct{})
+	s.HandleFunc(http.MethodGet, "/stuck", func(ctx context.Context, req Request) Response {
+		close(started)
+		<-release
+		return TextResponse(http.StatusOK, "done")
+	})
+	var hookErr = make(chan error, 1)
+	s.OnShutdown(func(ctx context.Context) error {
+		hookErr <- ctx.Err()
+		return nil
+	})
+
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ctx, cancel = context.WithCancel(context.Background())
+	var runErr = make(chan error, 1)
+	go func() { runErr <- s.ListenOn(ln).(*mseed).runContext(ctx, 50*time.Millisecond) }()
+
+	var clientErr = make(chan error, 1)
+	go func() {
+		var resp, err = http.Get("http://" + ln.Addr().String() + "/stuck")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err = <-runErr:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want deadline exceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("RunContext did not return after the shutdown timeout")
+	}
+	if err = <-hookErr; err != nil {
+		t.Fatalf("want a live ctx in shutdown hooks, got %v", err)
+	}
+	if err = <-clientErr; err == nil {
+		t.Fatalf("want the stuck connection closed")
+	}
+	if s.(*mseed).shutdownTimeout != defaultShutdownTimeout {
+		t.Fatalf("shutdown timeout changed to %v", s.(*mseed).shutdownTimeout)
+	}
+}
+
+func TestRunContextStartHookError(t *testing.T) {
+	var s = New()
+	var boom = errors.New("boom")
+	s.OnStart(func(ctx context.Context) error { return boom })
+
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Serve(ln); !errors.Is(err, boom) {
+		t.Fatalf("want start hook error, got %v", err)
+	}
+	if s.Ready() {
+		t.Fatalf("want not ready after failed start")
+	}
+}
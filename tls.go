@@ -0,0 +1,205 @@
+package seed
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultCertCheckInterval CertReloader 检查证书文件是否变化的默认间隔
+const defaultCertCheckInterval = 10 * time.Second
+
+// CertReloader 从文件加载证书，文件变化后自动重新加载，更换证书不需要重启进程
+//
+// 	在 TLS 握手时按间隔检查文件的修改时间(不需要额外的 goroutine)，变化时重新加载
+// 	重新加载失败(如文件只写了一半)时继续使用旧的证书，下次检查时重试
+// 	可以添加多个证书，握手时根据 SNI 选择，都不匹配时使用第一个
+// 	如:
+// 	var r, err = seed.NewCertReloader("a.crt", "a.key")
+// 	err = r.Add("b.crt", "b.key")
+// 	var cfg = seed.NewTLSConfig(seed.WithTLSCertReloader(r))
+type CertReloader struct {
+	mu        sync.RWMutex
+	pairs     []*certPair
+	interval  time.Duration
+	lastCheck time.Time
+}
+
+// certPair 一组证书与私钥文件
+type certPair struct {
+	certFile, keyFile string
+	certMod, keyMod   time.Time
+	cert              *tls.Certificate
+}
+
+// NewCertReloader 加载 certFile/keyFile 并返回 *CertReloader
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	var r = &CertReloader{interval: defaultCertCheckInterval, lastCheck: time.Now()}
+	if err := r.Add(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Add 添加一个证书，用于 SNI
+func (r *CertReloader) Add(certFile, keyFile string) error {
+	var p = &certPair{certFile: certFile, keyFile: keyFile}
+	if err := p.load(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.pairs = append(r.pairs, p)
+	r.mu.Unlock()
+	return nil
+}
+
+// SetCheckInterval 设置检查文件变化的间隔，默认 10s
+func (r *CertReloader) SetCheckInterval(interval time.Duration) *CertReloader {
+	r.mu.Lock()
+	r.interval = interval
+	r.mu.Unlock()
+	return r
+}
+
+// Reload 立即重新加载修改过的证书，返回遇到的错误
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastCheck = time.Now()
+	var errs []error
+	for _, p := range r.pairs {
+		if p.changed() {
+			errs = append(errs, p.load())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetCertificate 用于 tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	var due = time.Since(r.lastCheck) >= r.interval
+	r.mu.RUnlock()
+	if due {
+		_ = r.Reload()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.pairs {
+		if hello.SupportsCertificate(p.cert) == nil {
+			return p.cert, nil
+		}
+	}
+	return r.pairs[0].cert, nil
+}
+
+// load 读取证书文件并记录修改时间
+func (p *certPair) load() error {
+	var certMod, keyMod = modTime(p.certFile), modTime(p.keyFile)
+	var cert, err = tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	p.cert, p.certMod, p.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func (p *certPair) changed() bool {
+	return !modTime(p.certFile).Equal(p.certMod) || !modTime(p.keyFile).Equal(p.keyMod)
+}
+
+func modTime(name string) time.Time {
+	var info, err = os.Stat(name)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// TLSOption NewTLSConfig 的配置项
+type TLSOption func(cfg *tls.Config)
+
+// WithTLSCertReloader 使用 r 提供证书，支持热更新与 SNI
+func WithTLSCertReloader(r *CertReloader) TLSOption {
+	return func(cfg *tls.Config) {
+		cfg.Certificates = nil
+		cfg.GetCertificate = r.GetCertificate
+	}
+}
+
+// WithTLSCertificates 使用固定的证书
+func WithTLSCertificates(certs ...tls.Certificate) TLSOption {
+	return func(cfg *tls.Config) {
+		cfg.Certificates = append(cfg.Certificates, certs...)
+	}
+}
+
+// WithTLSClientCA 开启双向 TLS，客户端证书需要由 pool 中的 CA 签发
+//
+// 	required 为 false 时客户端可以不提供证书，提供了则必须有效
+// 	验证通过的客户端证书可以通过 Request.ClientCertificate 获取
+func WithTLSClientCA(pool *x509.CertPool, required bool) TLSOption {
+	return func(cfg *tls.Config) {
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if required {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+}
+
+// WithTLSMinVersion 设置最低的 TLS 版本，默认 TLS 1.2
+func WithTLSMinVersion(version uint16) TLSOption {
+	return func(cfg *tls.Config) {
+		cfg.MinVersion = version
+	}
+}
+
+// NewTLSConfig 返回使用安全默认值的 *tls.Config
+//
+// 	最低 TLS 1.2，TLS 1.2 只使用支持前向安全的 AEAD 加密套件，优先 X25519 曲线，支持 h2
+// 	证书通过 WithTLSCertReloader 或 WithTLSCertificates 设置
+func NewTLSConfig(opts ...TLSOption) *tls.Config {
+	var cfg = &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// certFileTLSConfig 在 base(为空时使用 NewTLSConfig) 的基础上使用 certFile/keyFile 并支持热更新
+func certFileTLSConfig(base *tls.Config, certFile, keyFile string) (*tls.Config, error) {
+	var r, err = NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var cfg *tls.Config
+	if base != nil {
+		cfg = base.Clone()
+	} else {
+		cfg = NewTLSConfig()
+	}
+	WithTLSCertReloader(r)(cfg)
+	return cfg, nil
+}
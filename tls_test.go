@@ -0,0 +1,183 @@
+package seed
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	pool   *x509.CertPool
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	var key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var tmpl = &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	var der, err = x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cert, _ = x509.ParseCertificate(der)
+	var pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool, serial: 1}
+}
+
+// issue 签发证书，dir 不为空时写入 dir/name.crt 与 dir/name.key
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	ca.serial++
+	var key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var tmpl = &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	var der, err = x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyDER, _ = x509.MarshalECPrivateKey(key)
+	var certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	var keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	var cert, _ = tls.X509KeyPair(certPEM, keyPEM)
+
+	if dir == "" {
+		return cert, "", ""
+	}
+	var certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err = os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, certFile, keyFile
+}
+
+// serveTLS 在随机端口上以 cfg 启动 s，返回地址
+func serveTLS(t *testing.T, s MSeed, cfg *tls.Config) string {
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetTLSConfig(cfg)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	return ln.Addr().String()
+}
+
+func tlsClient(addr string, cfg *tls.Config) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   cfg,
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func TestCertReloader(t *testing.T) {
+	var ca = newTestCA(t)
+	var dir = t.TempDir()
+	var _, aCert, aKey = ca.issue(t, dir, "a.test", x509.ExtKeyUsageServerAuth)
+	var _, bCert, bKey = ca.issue(t, dir, "b.test", x509.ExtKeyUsageServerAuth)
+
+	var r, err = NewCertReloader(aCert, aKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Add(bCert, bKey); err != nil {
+		t.Fatal(err)
+	}
+	r.SetCheckInterval(0)
+
+	var s = New()
+	s.HandleFunc(http.MethodGet, "/", func(ctx context.Context, req Request) Response {
+		return NopResponse(http.StatusOK)
+	})
+	var client = tlsClient(serveTLS(t, s, NewTLSConfig(WithTLSCertReloader(r))), &tls.Config{RootCAs: ca.pool})
+
+	var serial = func(host string) int64 {
+		var resp, err = client.Get("https://" + host + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		var leaf = resp.TLS.PeerCertificates[0]
+		if leaf.DNSNames[0] != host {
+			t.Fatalf("want certificate for %q, got %v", host, leaf.DNSNames)
+		}
+		return leaf.SerialNumber.Int64()
+	}
+
+	var before = serial("a.test")
+	serial("b.test")
+
+	// 轮换 a.test 的证书
+	ca.issue(t, dir, "a.test", x509.ExtKeyUsageServerAuth)
+	var future = time.Now().Add(time.Minute)
+	_ = os.Chtimes(aCert, future, future)
+	_ = os.Chtimes(aKey, future, future)
+	if after := serial("a.test"); after == before {
+		t.Fatalf("certificate not reloaded, serial still %d", after)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	var ca = newTestCA(t)
+	var serverCert, _, _ = ca.issue(t, "", "server.test", x509.ExtKeyUsageServerAuth)
+	var clientCert, _, _ = ca.issue(t, "", "client-1", x509.ExtKeyUsageClientAuth)
+
+	var s = New()
+	s.HandleFunc(http.MethodGet, "/whoami", func(ctx context.Context, req Request) Response {
+		var cert = req.ClientCertificate()
+		if cert == nil {
+			return TextResponse(http.StatusUnauthorized, "")
+		}
+		return TextResponse(http.StatusOK, cert.Subject.CommonName)
+	})
+	var addr = serveTLS(t, s, NewTLSConfig(WithTLSCertificates(serverCert), WithTLSClientCA(ca.pool, true)))
+
+	var client = tlsClient(addr, &tls.Config{RootCAs: ca.pool, Certificates: []tls.Certificate{clientCert}})
+	var resp, err = client.Get("https://server.test/whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bs, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(bs) != "client-1" {
+		t.Fatalf("want client identity %q, got %q", "client-1", bs)
+	}
+
+	if _, err = tlsClient(addr, &tls.Config{RootCAs: ca.pool}).Get("https://server.test/whoami"); err == nil {
+		t.Fatalf("want handshake error without client certificate")
+	}
+}
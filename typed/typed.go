@@ -0,0 +1,126 @@
+// Package typed 把 func(ctx, In) (Out, error) 形式的业务函数适配为 handler
+//
+// 	请求参数通过 bind 绑定并校验到 In，返回值通过 render 写出，如:
+// 	type CreateUser struct {
+// 		Tenant string `header:"X-Tenant"`
+// 		Name   string `json:"name" validate:"required"`
+// 	}
+// 	r.HandleStd(http.MethodPost, "/users", typed.New(func(ctx context.Context, in CreateUser) (*User, error) {
+// 		return svc.CreateUser(ctx, in)
+// 	}))
+// 	由于 bind 与 render 都依赖 seed，该功能放在单独的包中
+package typed
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/bind"
+	"github.com/ninthsoft/seed/render"
+)
+
+// Func 业务函数，In 为请求参数，Out 为响应数据
+type Func[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Option Handler 的配置项
+type Option func(o *options)
+
+type options struct {
+	binder    *bind.Binder
+	render    render.ResponseRender
+	bindTypes []bind.BindType
+}
+
+// WithBinder 使用指定的 Binder 绑定请求参数，默认 bind.DefaultBinder
+func WithBinder(b *bind.Binder) Option {
+	return func(o *options) {
+		o.binder = b
+	}
+}
+
+// WithRender 使用指定的 ResponseRender 写出响应，默认 render.JSON
+func WithRender(r render.ResponseRender) Option {
+	return func(o *options) {
+		o.render = r
+	}
+}
+
+// WithBindTypes 只绑定指定的来源，默认绑定所有来源，详见 bind.Should
+func WithBindTypes(bts ...bind.BindType) Option {
+	return func(o *options) {
+		o.bindTypes = bts
+	}
+}
+
+// Handler 由 New 创建，记录了输入输出类型
+//
+// 	实现了 http.Handler 与 seed.TypedHandler，可以直接用于 HandleStd
+type Handler[In, Out any] struct {
+	fn   Func[In, Out]
+	opts options
+}
+
+var _ seed.TypedHandler = &Handler[struct{}, struct{}]{}
+
+// New 返回 fn 对应的 *Handler
+//
+// 	In 可以是结构体或结构体指针，没有字段的结构体(如 struct{})不做绑定
+// 	绑定或校验失败时不调用 fn，直接写出错误
+// 	fn 返回的 render.CodeError(如 render.NewError)原样交给 render，其他错误作为 500 错误写出
+func New[In, Out any](fn Func[In, Out], opts ...Option) *Handler[In, Out] {
+	var h = &Handler[In, Out]{fn: fn, opts: options{binder: bind.DefaultBinder, render: render.JSON}}
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+	return h
+}
+
+// Typed 返回 fn 对应的 seed.HandlerFunc，用于 HandleFunc
+//
+// 	HandlerFunc 不携带输入输出类型，需要记录到路由信息与 OpenAPI 文档时使用 New 并通过 HandleStd/Route 注册
+func Typed[In, Out any](fn Func[In, Out], opts ...Option) seed.HandlerFunc {
+	return New(fn, opts...).Handle
+}
+
+// Handle 绑定参数、调用业务函数并写出结果
+func (h *Handler[In, Out]) Handle(ctx context.Context, req seed.Request) seed.Response {
+	var in In
+	var dst interface{} = &in
+	var t = reflect.TypeOf((*In)(nil)).Elem()
+	if t.Kind() == reflect.Pointer {
+		var v = reflect.New(t.Elem())
+		in, dst = v.Interface().(In), v.Interface()
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.NumField() > 0 {
+		if err := h.opts.binder.Should(req, dst, h.opts.bindTypes...); err != nil {
+			return h.opts.render(ctx, nil, err)
+		}
+	}
+
+	var out, err = h.fn(ctx, in)
+	if err != nil {
+		if _, ok := err.(render.CodeError); !ok {
+			err = render.NewError(err.Error(), 5000).WithStatus(http.StatusInternalServerError)
+		}
+		return h.opts.render(ctx, nil, err)
+	}
+	return h.opts.render(ctx, out, nil)
+}
+
+// HandlerFunc 返回对应的 seed.HandlerFunc
+func (h *Handler[In, Out]) HandlerFunc() seed.HandlerFunc {
+	return h.Handle
+}
+
+// ServeHTTP 实现 http.Handler
+func (h *Handler[In, Out]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.HandlerFunc().Handler().ServeHTTP(w, r)
+}
+
+// IOTypes 实现 seed.TypedHandler，返回 In 与 Out 的类型
+func (h *Handler[In, Out]) IOTypes() (in, out reflect.Type) {
+	return reflect.TypeOf((*In)(nil)).Elem(), reflect.TypeOf((*Out)(nil)).Elem()
+}
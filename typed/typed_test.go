@@ -0,0 +1,147 @@
+package typed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ninthsoft/seed"
+	"github.com/ninthsoft/seed/render"
+)
+
+type createUser struct {
+	Tenant string `path:"tenant"`
+	Name   string `json:"name" validate:"required"`
+}
+
+type user struct {
+	Tenant string `json:"tenant"`
+	Name   string `json:"name"`
+}
+
+func createUserFunc(ctx context.Context, in createUser) (user, error) {
+	if in.Name == "taken" {
+		return user{}, render.NewError("name taken", 4090).WithStatus(http.StatusConflict)
+	}
+	return user{Tenant: in.Tenant, Name: in.Name}, nil
+}
+
+func post(h http.Handler, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var r = seed.NewRouter()
+	r.HandleStd(http.MethodPost, "/:tenant/users", h)
+	var req = httptest.NewRequest(http.MethodPost, "/acme/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestHandler(t *testing.T) {
+	var h = New(createUserFunc)
+
+	var w, resp = post(h, `{"name":"alice"}`)
+	var data, _ = resp["data"].(map[string]interface{})
+	if w.Code != http.StatusOK || data["tenant"] != "acme" || data["name"] != "alice" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	if w, resp = post(h, `{}`); resp["details"] == nil {
+		t.Fatalf("want validation details, got %s", w.Body.String())
+	}
+
+	if w, resp = post(h, `{"name":"taken"}`); w.Code != http.StatusConflict || resp["code"] != float64(4090) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	var in, out = h.IOTypes()
+	if in != reflect.TypeOf(createUser{}) || out != reflect.TypeOf(user{}) {
+		t.Fatalf("unexpected io types %v %v", in, out)
+	}
+	var _ seed.TypedHandler = h
+}
+
+func TestHandlerPointerInputAndRender(t *testing.T) {
+	var rendered error
+	var h = New(func(ctx context.Context, in *createUser) (*user, error) {
+		return &user{Tenant: in.Tenant, Name: in.Name}, nil
+	}, WithRender(func(ctx context.Context, data interface{}, err error) seed.Response {
+		rendered = err
+		if err != nil {
+			return seed.TextResponse(http.StatusBadRequest, err.Error())
+		}
+		var u = data.(*user)
+		return seed.TextResponse(http.StatusOK, u.Tenant+"/"+u.Name)
+	}))
+
+	if w, _ := post(h, `{"name":"bob"}`); w.Body.String() != "acme/bob" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+	if post(h, `{"name":`); rendered == nil {
+		t.Fatalf("want bind error passed to render")
+	}
+}
+
+func TestTypedEmptyInput(t *testing.T) {
+	var boom = render.NewError("boom", 5000)
+	var r = seed.NewRouter()
+	r.HandleFunc(http.MethodGet, "/ping", Typed(func(ctx context.Context, _ struct{}) (string, error) {
+		return "pong", nil
+	}))
+	r.HandleFunc(http.MethodGet, "/fail", Typed(func(ctx context.Context, _ struct{}) (string, error) {
+		return "", boom
+	}))
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if !strings.Contains(w.Body.String(), `"data":"pong"`) {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if !strings.Contains(w.Body.String(), `"code":5000`) {
+		t.Fatalf("want error response, got %s", w.Body.String())
+	}
+}
+
+func TestTypedWrapsPlainErrors(t *testing.T) {
+	var r = seed.NewRouter()
+	r.HandleFunc(http.MethodGet, "/fail", Typed(func(ctx context.Context, _ struct{}) (string, error) {
+		return "", errors.New("db down")
+	}))
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusInternalServerError || resp["code"] != float64(5000) || resp["msg"] != "db down" {
+		t.Fatalf("want a 500 error response, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlerRecordsIOTypes(t *testing.T) {
+	var r = seed.NewRouter()
+	r.HandleStd(http.MethodPost, "/:tenant/users", New(createUserFunc))
+	r.HandleStd(http.MethodPost, "/:tenant/admins", New(func(ctx context.Context, in *createUser) (*user, error) {
+		return nil, nil
+	}))
+
+	var doc = seed.NewOpenAPI(r, seed.OpenAPIOptions{})
+	for _, p := range []string{"/{tenant}/users", "/{tenant}/admins"} {
+		var op = doc.Paths[p]["post"]
+		if op.RequestBody == nil || op.RequestBody.Content["application/json"].Schema.Properties["name"] == nil {
+			t.Fatalf("want request body for %s", p)
+		}
+		if op.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/user" {
+			t.Fatalf("want response schema for %s", p)
+		}
+	}
+}
@@ -3,21 +3,93 @@ package autoprocs
 import (
 	"io"
 	"log"
+	"math"
 	"os"
 	"runtime"
 )
 
 const _EnvNameGoMaxProcs = "GOMAXPROCS"
 
-// AutoSet 自动设置 GOMAXPROCS 的值
-func AutoSet(logWriter io.Writer) {
-	var logger = log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)
+// Option AutoSet 的配置项
+type Option func(c *config)
+
+type config struct {
+	// root 文件系统根目录，读取 /proc 与 cgroup 文件时以此为前缀
+	root  string
+	round func(quota float64) int
+	min   int
+}
+
+// WithMinProcs 设置 GOMAXPROCS 的下限，默认为 1
+func WithMinProcs(n int) Option {
+	return func(c *config) {
+		c.min = n
+	}
+}
+
+// WithRoundFunc 设置 CPU 配额(核数，可能是小数)换算为 GOMAXPROCS 的方式，默认向下取整
+//
+// 	如需向上取整可以传入 func(q float64) int { return int(math.Ceil(q)) }
+func WithRoundFunc(f func(quota float64) int) Option {
+	return func(c *config) {
+		c.round = f
+	}
+}
+
+// withRoot 设置文件系统根目录，用于测试
+func withRoot(root string) Option {
+	return func(c *config) {
+		c.root = root
+	}
+}
+
+// AutoSet 根据容器(cgroup v1/v2)的 CPU 配额设置 GOMAXPROCS 的值
+//
+// 	需要在 main 中显式调用，日志写入 logWriter(为 nil 时不输出)
+// 	若环境变量 GOMAXPROCS 有值或者没有 CPU 配额，将不进行设置
+// 	undo: 恢复为调用之前的 GOMAXPROCS 值，任何情况下都不为 nil
+func AutoSet(logWriter io.Writer, opts ...Option) (undo func(), err error) {
+	if logWriter == nil {
+		logWriter = io.Discard
+	}
+	var logger = log.New(logWriter, "", log.LstdFlags)
+	var c = &config{
+		root:  "/",
+		round: func(quota float64) int { return int(math.Floor(quota)) },
+		min:   1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
 
 	var last = runtime.GOMAXPROCS(-1)
-	defer func() {
-		var current = runtime.GOMAXPROCS(-1)
-		logger.Printf("[INFO] automaxprocs current GOMAXPROCS=%v, last GOMAXPROCS=%v\n", current, last)
-	}()
+	undo = func() {}
+
+	if v := os.Getenv(_EnvNameGoMaxProcs); v != "" {
+		logger.Printf("[INFO] autoprocs honoring GOMAXPROCS=%q set in environment\n", v)
+		return undo, nil
+	}
+
+	quota, ok, err := cpuQuota(c.root)
+	if err != nil {
+		logger.Printf("[ERROR] autoprocs read CPU quota failed: %v\n", err)
+		return undo, err
+	}
+	if !ok {
+		logger.Printf("[INFO] autoprocs no CPU quota found, leaving GOMAXPROCS=%v\n", last)
+		return undo, nil
+	}
+
+	var num = c.round(quota)
+	if num < c.min {
+		num = c.min
+	}
+	if _, ok = Set(num); !ok {
+		logger.Printf("[INFO] autoprocs invalid GOMAXPROCS=%v for CPU quota %v, leaving GOMAXPROCS=%v\n", num, quota, last)
+		return undo, nil
+	}
+	logger.Printf("[INFO] autoprocs current GOMAXPROCS=%v, last GOMAXPROCS=%v, CPU quota %v\n", num, last, quota)
+	return func() { runtime.GOMAXPROCS(last) }, nil
 }
 
 // Set 设置 GOMAXPROCS 值
@@ -30,7 +102,3 @@ func Set(num int) (cpuNum int, ok bool) {
 	}
 	return runtime.GOMAXPROCS(num), num >= 1
 }
-
-func init() {
-	AutoSet(os.Stderr)
-}
@@ -0,0 +1,180 @@
+package autoprocs
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const (
+	_TestCgroupV2 = "0::/kubepods/pod1/c1\n"
+	_TestMountV2  = "30 23 0:26 / /sys/fs/cgroup rw,nosuid shared:4 - cgroup2 cgroup2 rw,nsdelegate\n"
+
+	_TestCgroupV1 = "12:memory:/kubepods/pod1/c1\n4:cpu,cpuacct:/kubepods/pod1/c1\n0::/\n"
+	_TestMountV1  = "33 25 0:29 / /sys/fs/cgroup/memory rw - cgroup cgroup rw,memory\n" +
+		"34 25 0:30 /kubepods/pod1/c1 /sys/fs/cgroup/cpu,cpuacct rw - cgroup cgroup rw,cpu,cpuacct\n"
+)
+
+// fakeRoot 在临时目录中创建一个假的文件系统，files 为 相对路径 -> 内容
+func fakeRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var root = t.TempDir()
+	for name, content := range files {
+		var file = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestCPUQuota(t *testing.T) {
+	var cases = []struct {
+		name  string
+		files map[string]string
+		quota float64
+		ok    bool
+	}{
+		{
+			name: "cgroup v2",
+			files: map[string]string{
+				_ProcSelfCgroup:                          _TestCgroupV2,
+				_ProcSelfMountInfo:                       _TestMountV2,
+				"sys/fs/cgroup/kubepods/pod1/c1/cpu.max": "250000 100000\n",
+			},
+			quota: 2.5,
+			ok:    true,
+		},
+		{
+			name: "cgroup v2 without limit",
+			files: map[string]string{
+				_ProcSelfCgroup:                          _TestCgroupV2,
+				_ProcSelfMountInfo:                       _TestMountV2,
+				"sys/fs/cgroup/kubepods/pod1/c1/cpu.max": "max 100000\n",
+			},
+		},
+		{
+			name: "cgroup v2 namespace",
+			files: map[string]string{
+				_ProcSelfCgroup:               "0::/\n",
+				_ProcSelfMountInfo:            _TestMountV2,
+				"sys/fs/cgroup/cpu.max":       "50000 100000\n",
+				"sys/fs/cgroup/other/cpu.max": "max 100000\n",
+			},
+			quota: 0.5,
+			ok:    true,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				_ProcSelfCgroup:    _TestCgroupV1,
+				_ProcSelfMountInfo: _TestMountV1,
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "300000\n",
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+			},
+			quota: 3,
+			ok:    true,
+		},
+		{
+			name: "cgroup v1 without limit",
+			files: map[string]string{
+				_ProcSelfCgroup:    _TestCgroupV1,
+				_ProcSelfMountInfo: _TestMountV1,
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "-1\n",
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+			},
+		},
+		{
+			name:  "not in a cgroup",
+			files: map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var quota, ok, err = cpuQuota(fakeRoot(t, c.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != c.ok || quota != c.quota {
+				t.Fatalf("want quota %v (ok=%v), got %v (ok=%v)", c.quota, c.ok, quota, ok)
+			}
+		})
+	}
+}
+
+func TestCPUQuotaInvalid(t *testing.T) {
+	var root = fakeRoot(t, map[string]string{
+		_ProcSelfCgroup:                          _TestCgroupV2,
+		_ProcSelfMountInfo:                       _TestMountV2,
+		"sys/fs/cgroup/kubepods/pod1/c1/cpu.max": "lots 100000\n",
+	})
+	if _, _, err := cpuQuota(root); err == nil {
+		t.Fatal("want error for invalid cpu.max")
+	}
+}
+
+func TestAutoSet(t *testing.T) {
+	t.Setenv(_EnvNameGoMaxProcs, "")
+	var before = runtime.GOMAXPROCS(-1)
+	var root = fakeRoot(t, map[string]string{
+		_ProcSelfCgroup:                          _TestCgroupV2,
+		_ProcSelfMountInfo:                       _TestMountV2,
+		"sys/fs/cgroup/kubepods/pod1/c1/cpu.max": "150000 100000\n",
+	})
+
+	var cases = []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "floor", want: 1},
+		{name: "ceil", opts: []Option{WithRoundFunc(func(q float64) int { return int(math.Ceil(q)) })}, want: 2},
+		{name: "min", opts: []Option{WithMinProcs(4)}, want: 4},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var undo, err = AutoSet(&buf, append(c.opts, withRoot(root))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := runtime.GOMAXPROCS(-1); got != c.want {
+				t.Fatalf("want GOMAXPROCS=%d, got %d", c.want, got)
+			}
+			if !strings.Contains(buf.String(), "GOMAXPROCS") {
+				t.Fatalf("want log written to the given writer, got %q", buf.String())
+			}
+			undo()
+			if got := runtime.GOMAXPROCS(-1); got != before {
+				t.Fatalf("want undo to restore GOMAXPROCS=%d, got %d", before, got)
+			}
+		})
+	}
+}
+
+func TestAutoSetHonorsEnv(t *testing.T) {
+	t.Setenv(_EnvNameGoMaxProcs, "7")
+	var before = runtime.GOMAXPROCS(-1)
+	var root = fakeRoot(t, map[string]string{
+		_ProcSelfCgroup:                          _TestCgroupV2,
+		_ProcSelfMountInfo:                       _TestMountV2,
+		"sys/fs/cgroup/kubepods/pod1/c1/cpu.max": "100000 100000\n",
+	})
+
+	var undo, err = AutoSet(nil, withRoot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer undo()
+	if got := runtime.GOMAXPROCS(-1); got != before {
+		t.Fatalf("want GOMAXPROCS left at %d, got %d", before, got)
+	}
+}
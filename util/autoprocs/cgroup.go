@@ -0,0 +1,194 @@
+package autoprocs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	_ProcSelfCgroup    = "proc/self/cgroup"
+	_ProcSelfMountInfo = "proc/self/mountinfo"
+
+	_CgroupV2CPUMax     = "cpu.max"
+	_CgroupV1CFSQuotaUs = "cpu.cfs_quota_us"
+	_CgroupV1CFSPeriod  = "cpu.cfs_period_us"
+)
+
+// mountPoint /proc/self/mountinfo 中 cgroup 相关的一行
+type mountPoint struct {
+	root         string
+	mountPoint   string
+	fsType       string
+	superOptions []string
+}
+
+// dir 把 cgroup 路径换算为该挂载点下的目录
+func (m mountPoint) dir(cgroupPath string) string {
+	var rel, err = filepath.Rel(m.root, cgroupPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		// cgroup namespace 下挂载点即为自身的 cgroup
+		return m.mountPoint
+	}
+	return filepath.Join(m.mountPoint, rel)
+}
+
+// cpuQuota 返回当前进程 cgroup 的 CPU 配额(核数)
+//
+// 	优先使用 cgroup v1 的 cpu 控制器，其次是 cgroup v2
+// 	ok 为 false 表示不在 cgroup 中或者没有限制
+func cpuQuota(root string) (quota float64, ok bool, err error) {
+	cgroups, err := parseCgroups(filepath.Join(root, _ProcSelfCgroup))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	mounts, err := parseMountInfo(filepath.Join(root, _ProcSelfMountInfo))
+	if err != nil {
+		return 0, false, err
+	}
+
+	if path, has := cgroups["cpu"]; has {
+		for _, m := range mounts {
+			if m.fsType == "cgroup" && slices.Contains(m.superOptions, "cpu") {
+				return readCFSQuota(filepath.Join(root, m.dir(path)))
+			}
+		}
+	}
+	if path, has := cgroups[""]; has {
+		for _, m := range mounts {
+			if m.fsType == "cgroup2" {
+				return readCPUMax(filepath.Join(root, m.dir(path), _CgroupV2CPUMax))
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// parseCgroups 解析 /proc/self/cgroup，返回 控制器 -> cgroup 路径
+//
+// 	格式为 hierarchy-ID:controller-list:cgroup-path，cgroup v2 的 controller-list 为空
+func parseCgroups(file string) (map[string]string, error) {
+	var f, err = os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var cgroups = map[string]string{}
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var fields = strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			cgroups[""] = fields[2]
+			continue
+		}
+		for _, c := range strings.Split(fields[1], ",") {
+			cgroups[c] = fields[2]
+		}
+	}
+	return cgroups, scanner.Err()
+}
+
+// parseMountInfo 解析 /proc/self/mountinfo 中的 cgroup 挂载点
+//
+// 	格式如 36 35 98:0 /root /mnt rw,noatime master:1 - cgroup cgroup rw,cpu,cpuacct
+func parseMountInfo(file string) ([]mountPoint, error) {
+	var f, err = os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var mounts []mountPoint
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var fields = strings.Fields(scanner.Text())
+		var sep = slices.Index(fields, "-")
+		if sep < 5 || len(fields) < sep+4 {
+			continue
+		}
+		var m = mountPoint{
+			root:         fields[3],
+			mountPoint:   fields[4],
+			fsType:       fields[sep+1],
+			superOptions: strings.Split(fields[sep+3], ","),
+		}
+		if m.fsType == "cgroup" || m.fsType == "cgroup2" {
+			mounts = append(mounts, m)
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// readCPUMax 读取 cgroup v2 的 cpu.max，格式为 "$MAX $PERIOD"，$MAX 为 max 时表示不限制
+func readCPUMax(file string) (quota float64, ok bool, err error) {
+	var bs []byte
+	if bs, err = os.ReadFile(file); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	var fields = strings.Fields(string(bs))
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, false, fmt.Errorf("invalid %s content %q", file, bs)
+	}
+	if fields[0] == "max" {
+		return 0, false, nil
+	}
+	var period = 100000.0
+	if len(fields) == 2 {
+		if period, err = strconv.ParseFloat(fields[1], 64); err != nil {
+			return 0, false, fmt.Errorf("invalid %s period: %w", file, err)
+		}
+	}
+	limit, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s max: %w", file, err)
+	}
+	if period <= 0 {
+		return 0, false, fmt.Errorf("invalid %s period %v", file, period)
+	}
+	return limit / period, true, nil
+}
+
+// readCFSQuota 读取 cgroup v1 的 cpu.cfs_quota_us 与 cpu.cfs_period_us，quota 为 -1 时表示不限制
+func readCFSQuota(dir string) (quota float64, ok bool, err error) {
+	var cfsQuota, cfsPeriod float64
+	if cfsQuota, err = readFloat(filepath.Join(dir, _CgroupV1CFSQuotaUs)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if cfsQuota <= 0 {
+		return 0, false, nil
+	}
+	if cfsPeriod, err = readFloat(filepath.Join(dir, _CgroupV1CFSPeriod)); err != nil {
+		return 0, false, err
+	}
+	if cfsPeriod <= 0 {
+		return 0, false, fmt.Errorf("invalid %s %v", _CgroupV1CFSPeriod, cfsPeriod)
+	}
+	return cfsQuota / cfsPeriod, true, nil
+}
+
+func readFloat(file string) (float64, error) {
+	var bs, err = os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(bs)), 64)
+}